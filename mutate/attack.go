@@ -0,0 +1,192 @@
+package mutate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Attack types, named after their Burp Intruder counterparts
+const (
+	AttackSniper       = "sniper"        // One insertion point at a time, every payload
+	AttackBatteringRam = "battering-ram" // The same payload at every insertion point
+	AttackPitchfork    = "pitchfork"     // The n-th payload of each set at its insertion point
+	AttackClusterBomb  = "cluster-bomb"  // Every combination of the payload sets
+)
+
+// AllAttacks lists the supported attack types
+var AllAttacks = []string{AttackSniper, AttackBatteringRam, AttackPitchfork, AttackClusterBomb}
+
+// ErrTooManyVariants is reported when an attack would exceed Mutator.MaxVariants
+var ErrTooManyVariants = errors.New("too many variants")
+
+// payloadSet returns the payloads used at insertion point i. Insertion
+// points past the last set reuse it.
+func payloadSet(sets [][]string, i int) []string {
+	return sets[min(i, len(sets)-1)]
+}
+
+// AttackCount returns the number of variants an attack generates for n
+// insertion points and the given payload sets, math.MaxInt when the count
+// does not fit in an int
+func AttackCount(attack string, n int, sets [][]string) int {
+	lens := make([]int, len(sets))
+	for i, set := range sets {
+		lens[i] = len(set)
+	}
+	return attackCount(attack, n, lens)
+}
+
+// attackCount is AttackCount with the number of payloads of each set, so
+// that generated sets can be counted without collecting them
+func attackCount(attack string, n int, lens []int) int {
+	if n == 0 || len(lens) == 0 {
+		return 0
+	}
+	setLen := func(i int) int {
+		return lens[min(i, len(lens)-1)]
+	}
+	switch attack {
+	case AttackSniper:
+		count := 0
+		for i := 0; i < n; i++ {
+			count = addCount(count, setLen(i))
+		}
+		return count
+	case AttackBatteringRam:
+		return lens[0]
+	case AttackPitchfork:
+		count := lens[0]
+		for i := 1; i < n; i++ {
+			count = min(count, setLen(i))
+		}
+		return count
+	case AttackClusterBomb:
+		count := 1
+		for i := 0; i < n; i++ {
+			if setLen(i) == 0 {
+				return 0
+			}
+		}
+		for i := 0; i < n; i++ {
+			count = mulCount(count, setLen(i))
+		}
+		return count
+	}
+	return 0
+}
+
+// checkAttackLimit returns ErrTooManyVariants when an attack generating
+// count variants exceeds limit, unless limit is 0
+func checkAttackLimit(attack string, count, limit int) error {
+	if limit > 0 && count > limit {
+		return fmt.Errorf("%w: %s attack would generate %d variants, the limit is %d", ErrTooManyVariants, attack, count, limit)
+	}
+	return nil
+}
+
+// addCount returns a+b for non-negative counts, math.MaxInt when it
+// overflows
+func addCount(a, b int) int {
+	return min(a, math.MaxInt-b) + b
+}
+
+// mulCount returns a*b for non-negative counts, math.MaxInt when it
+// overflows
+func mulCount(a, b int) int {
+	if b != 0 && a > math.MaxInt/b {
+		return math.MaxInt
+	}
+	return a * b
+}
+
+// RunAttack inserts payloads at the spans of target following the attack
+// type and calls emit with every variant and the payload used at each span.
+// Nothing is generated when the attack would produce more than limit
+// variants, unless limit is 0.
+func RunAttack(target string, spans []Span, sets [][]string, ftype, attack string, limit int, emit func(value string, payloads []string)) error {
+	if len(sets) == 0 {
+		return fmt.Errorf("no payloads for %s attack", attack)
+	}
+	if err := checkAttackLimit(attack, AttackCount(attack, len(spans), sets), limit); err != nil {
+		return err
+	}
+
+	spans = numberSpans(spans)
+	generate := func(spans []Span, payloads []string) error {
+		v, err := spliceEach(target, spans, payloads, ftype)
+		if err != nil {
+			return err
+		}
+		emit(v, payloads)
+		return nil
+	}
+
+	switch attack {
+	case AttackSniper:
+		for i, span := range spans {
+			for _, p := range payloadSet(sets, i) {
+				if err := generate([]Span{span}, []string{p}); err != nil {
+					return err
+				}
+			}
+		}
+
+	case AttackBatteringRam:
+		if len(spans) == 0 {
+			return nil
+		}
+		for _, p := range sets[0] {
+			payloads := make([]string, len(spans))
+			for i := range payloads {
+				payloads[i] = p
+			}
+			if err := generate(spans, payloads); err != nil {
+				return err
+			}
+		}
+
+	case AttackPitchfork:
+		for j := 0; j < AttackCount(attack, len(spans), sets); j++ {
+			payloads := make([]string, len(spans))
+			for i := range payloads {
+				payloads[i] = payloadSet(sets, i)[j]
+			}
+			if err := generate(spans, payloads); err != nil {
+				return err
+			}
+		}
+
+	case AttackClusterBomb:
+		if AttackCount(attack, len(spans), sets) == 0 {
+			return nil
+		}
+		// Odometer over the payload sets, the last insertion point changing fastest
+		indexes := make([]int, len(spans))
+		for {
+			payloads := make([]string, len(spans))
+			for i, j := range indexes {
+				payloads[i] = payloadSet(sets, i)[j]
+			}
+			if err := generate(spans, payloads); err != nil {
+				return err
+			}
+
+			i := len(indexes) - 1
+			for ; i >= 0; i-- {
+				indexes[i]++
+				if indexes[i] < len(payloadSet(sets, i)) {
+					break
+				}
+				indexes[i] = 0
+			}
+			if i < 0 {
+				return nil
+			}
+		}
+
+	default:
+		return fmt.Errorf("invalid attack type: %s", attack)
+	}
+	return nil
+}
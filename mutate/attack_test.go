@@ -0,0 +1,105 @@
+package mutate
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// attackTarget has three insertion points, the values of x, y and z
+const attackTarget = "x=1&y=2&z=3"
+
+var attackSpans = []Span{{Start: 2, End: 3}, {Start: 6, End: 7}, {Start: 10, End: 11}}
+
+func TestRunAttack(t *testing.T) {
+	tests := []struct {
+		name   string
+		attack string
+		points int
+		sets   [][]string
+		want   []string
+	}{
+		{"sniper", AttackSniper, 2, [][]string{{"A", "B"}},
+			[]string{"x=A&y=2&z=3 A", "x=B&y=2&z=3 B", "x=1&y=A&z=3 A", "x=1&y=B&z=3 B"}},
+		{"sniper sets per point", AttackSniper, 3, [][]string{{"A"}, {"B", "C"}},
+			[]string{"x=A&y=2&z=3 A", "x=1&y=B&z=3 B", "x=1&y=C&z=3 C", "x=1&y=2&z=B B", "x=1&y=2&z=C C"}},
+		{"battering-ram", AttackBatteringRam, 3, [][]string{{"A", "B"}},
+			[]string{"x=A&y=A&z=A A,A,A", "x=B&y=B&z=B B,B,B"}},
+		{"pitchfork", AttackPitchfork, 2, [][]string{{"A", "B"}, {"C", "D"}},
+			[]string{"x=A&y=C&z=3 A,C", "x=B&y=D&z=3 B,D"}},
+		{"pitchfork truncated", AttackPitchfork, 2, [][]string{{"A", "B", "C"}, {"D", "E"}},
+			[]string{"x=A&y=D&z=3 A,D", "x=B&y=E&z=3 B,E"}},
+		{"pitchfork last set reused", AttackPitchfork, 3, [][]string{{"A", "B"}, {"C", "D"}},
+			[]string{"x=A&y=C&z=C A,C,C", "x=B&y=D&z=D B,D,D"}},
+		{"cluster-bomb", AttackClusterBomb, 2, [][]string{{"A", "B"}, {"C", "D"}},
+			[]string{"x=A&y=C&z=3 A,C", "x=A&y=D&z=3 A,D", "x=B&y=C&z=3 B,C", "x=B&y=D&z=3 B,D"}},
+		{"cluster-bomb last set reused", AttackClusterBomb, 3, [][]string{{"A"}, {"B", "C"}},
+			[]string{"x=A&y=B&z=B A,B,B", "x=A&y=B&z=C A,B,C", "x=A&y=C&z=B A,C,B", "x=A&y=C&z=C A,C,C"}},
+		{"cluster-bomb empty set", AttackClusterBomb, 2, [][]string{{"A"}, {}}, nil},
+		{"no insertion points", AttackSniper, 0, [][]string{{"A"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := RunAttack(attackTarget, attackSpans[:tt.points], tt.sets, "replace", tt.attack, 0, func(value string, payloads []string) {
+				got = append(got, value+" "+strings.Join(payloads, ","))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if n := AttackCount(tt.attack, tt.points, tt.sets); n != len(tt.want) {
+				t.Errorf("AttackCount: got %d, want %d", n, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestRunAttackErrors(t *testing.T) {
+	emitted := 0
+	emit := func(string, []string) { emitted++ }
+
+	err := RunAttack(attackTarget, attackSpans, [][]string{{"A", "B"}}, "replace", AttackClusterBomb, 7, emit)
+	if !errors.Is(err, ErrTooManyVariants) {
+		t.Errorf("over the limit: got %v, want ErrTooManyVariants", err)
+	}
+	if err := RunAttack(attackTarget, attackSpans, [][]string{{"A", "B"}}, "replace", AttackClusterBomb, 8, emit); err != nil {
+		t.Errorf("at the limit: %v", err)
+	}
+	if emitted != 8 {
+		t.Errorf("got %d variants, want 8", emitted)
+	}
+	if err := RunAttack(attackTarget, attackSpans, nil, "replace", AttackSniper, 0, emit); err == nil {
+		t.Error("no payload sets: got no error")
+	}
+	if err := RunAttack(attackTarget, attackSpans, [][]string{{"A"}}, "replace", "bogus", 0, emit); err == nil {
+		t.Error("invalid attack: got no error")
+	}
+}
+
+func TestAttackCountOverflow(t *testing.T) {
+	tests := []struct {
+		name   string
+		attack string
+		points int
+		lens   []int
+		want   int
+	}{
+		{"sniper", AttackSniper, 3, []int{math.MaxInt / 2}, math.MaxInt},
+		{"cluster-bomb", AttackClusterBomb, 64, []int{2}, math.MaxInt},
+		{"cluster-bomb fits", AttackClusterBomb, 30, []int{2}, 1 << 30},
+		{"cluster-bomb empty set", AttackClusterBomb, 64, []int{2, 0}, 0},
+		{"pitchfork", AttackPitchfork, 3, []int{math.MaxInt, 5}, 5},
+		{"battering-ram", AttackBatteringRam, 3, []int{math.MaxInt}, math.MaxInt},
+		{"invalid", "bogus", 3, []int{2}, 0},
+	}
+	for _, tt := range tests {
+		if got := attackCount(tt.attack, tt.points, tt.lens); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package mutate
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultExtensions lists the file extensions the path parts match by default
+var DefaultExtensions = []string{"php", "asp", "aspx", "jsp", "jspx", "xml"}
+
+// pathRegexps holds the regular expressions built from an extension list
+type pathRegexps struct {
+	suffix  *regexp.Regexp // For URL paths
+	segment *regexp.Regexp // For path segment
+	ext     *regexp.Regexp // For file extensions in paths
+}
+
+func init() {
+	register("param-value", func(PartOptions) FuzzingPart {
+		return NewRawPart("param-value", AllFuzzingTypes, AllFuzzingModes, paramValueSpans, rawParamValueSpans)
+	})
+	register("param-name", func(PartOptions) FuzzingPart {
+		return NewRawPart("param-name", AllFuzzingTypes, AllFuzzingModes, paramNameSpans, rawParamNameSpans)
+	})
+	register("path-suffix", func(o PartOptions) FuzzingPart {
+		return NewPart("path-suffix", AllFuzzingTypes, AllFuzzingModes, regexSpans(extensionRegexps(o.extensions()).suffix, 1))
+	})
+	register("path-suffix-slash", func(o PartOptions) FuzzingPart {
+		return NewPart("path-suffix-slash", []string{"replace"}, AllFuzzingModes, suffixSlashSpans(extensionRegexps(o.extensions()).suffix))
+	})
+	register("path-segment", func(o PartOptions) FuzzingPart {
+		return NewPart("path-segment", AllFuzzingTypes, AllFuzzingModes, regexSpans(extensionRegexps(o.extensions()).segment, 2))
+	})
+	register("path-ext", func(o PartOptions) FuzzingPart {
+		return NewPart("path-ext", AllFuzzingTypes, AllFuzzingModes, regexSpans(extensionRegexps(o.extensions()).ext, 2))
+	})
+	register("headers", func(o PartOptions) FuzzingPart {
+		return NewRawPart("headers", AllFuzzingTypes, AllFuzzingModes, headerSpans(o.headers()), rawHeaderSpans(o.headers()))
+	})
+	register("add-headers", func(o PartOptions) FuzzingPart {
+		return NewRawPart("add-headers", AllFuzzingTypes, AllFuzzingModes, nil, addHeaderSpans(o.addHeaders()))
+	})
+	Register(NewRawPart("cookie-value", AllFuzzingTypes, AllFuzzingModes, nil, cookieSpans(false)))
+	Register(NewRawPart("cookie-name", AllFuzzingTypes, AllFuzzingModes, nil, cookieSpans(true)))
+	Register(NewRawPart("header-param", AllFuzzingTypes, AllFuzzingModes, nil, headerParamSpans))
+	Register(NewPart("fragment", AllFuzzingTypes, AllFuzzingModes, fragmentSpans))
+	Register(NewPart("fragment-param", AllFuzzingTypes, AllFuzzingModes, fragmentParamSpans))
+	Register(hostPart{})
+	Register(NewPart("path-each", AllFuzzingTypes, AllFuzzingModes, pathEachSpans))
+	register("json-value", func(o PartOptions) FuzzingPart {
+		return NewRawPart("json-value", AllFuzzingTypes, AllFuzzingModes, nil, func(req *Request) []Span {
+			return jsonSpans(req, o.jsonValues(), false)
+		})
+	})
+	register("json-key", func(o PartOptions) FuzzingPart {
+		return NewRawPart("json-key", AllFuzzingTypes, AllFuzzingModes, nil, func(req *Request) []Span {
+			return jsonSpans(req, o.jsonValues(), true)
+		})
+	})
+	register("xml", func(o PartOptions) FuzzingPart {
+		return NewRawPart("xml", AllFuzzingTypes, AllFuzzingModes, nil, func(req *Request) []Span {
+			return xmlSpans(req, o.XMLDoctype)
+		})
+	})
+	Register(NewRawPart("multipart", AllFuzzingTypes, AllFuzzingModes, nil, multipartSpans))
+	register("markers", func(o PartOptions) FuzzingPart {
+		open, close := o.markers()
+		return markerPart{open: open, close: close}
+	})
+}
+
+var pathRegexpCache sync.Map
+
+// extensionRegexps returns the regular expressions of the path parts for
+// the given file extensions
+func extensionRegexps(extensions []string) *pathRegexps {
+	key := strings.Join(extensions, "\n")
+	if res, ok := pathRegexpCache.Load(key); ok {
+		return res.(*pathRegexps)
+	}
+
+	var quoted []string
+	for _, ext := range extensions {
+		quoted = append(quoted, regexp.QuoteMeta(strings.TrimPrefix(strings.TrimSpace(ext), ".")))
+	}
+	// Longer extensions first, so that aspx is not matched as asp
+	slices.SortStableFunc(quoted, func(a, b string) int { return len(b) - len(a) })
+	alternation := strings.Join(quoted, "|")
+
+	res := &pathRegexps{
+		suffix:  regexp.MustCompile(`/([^/]+\.(` + alternation + `))`),
+		segment: regexp.MustCompile(`^((?:https?://[^/]+)?/(?:[^/]+/)*)([^/]+)/([^/]+\.(` + alternation + `))`),
+		ext:     regexp.MustCompile(`/([^/]+)\.(` + alternation + `)`),
+	}
+	pathRegexpCache.Store(key, res)
+	return res
+}
+
+// regexSpans returns a locator for the given capture group of every match of re
+func regexSpans(re *regexp.Regexp, group int) func(string) []Span {
+	return func(target string) []Span {
+		var spans []Span
+		for _, match := range re.FindAllStringSubmatchIndex(target, -1) {
+			spans = append(spans, Span{Start: match[2*group], End: match[2*group+1]})
+		}
+		return spans
+	}
+}
+
+// suffixSlashSpans returns a locator appending a new path segment after
+// every file name matched by suffix
+func suffixSlashSpans(suffix *regexp.Regexp) func(string) []Span {
+	return func(target string) []Span {
+		var spans []Span
+		for _, match := range suffix.FindAllStringIndex(target, -1) {
+			spans = append(spans, Span{Start: match[1], End: match[1], Wrap: func(v string) string { return "/" + v }})
+		}
+		return spans
+	}
+}
+
+// pathEachSpans locates every non-empty segment of the URL path, without
+// its matrix parameters, whatever the file extension
+func pathEachSpans(target string) []Span {
+	l, ok := parseURL(target)
+	if !ok {
+		return nil
+	}
+
+	var spans []Span
+	for pos := l.PathStart; pos < l.PathEnd; {
+		end := l.PathEnd
+		if i := strings.IndexByte(target[pos:l.PathEnd], '/'); i >= 0 {
+			end = pos + i
+		}
+		segmentEnd := end
+		if i := strings.IndexByte(target[pos:end], ';'); i >= 0 {
+			segmentEnd = pos + i
+		}
+		if segmentEnd > pos {
+			spans = append(spans, Span{Start: pos, End: segmentEnd})
+		}
+		pos = end + 1
+	}
+	return spans
+}
@@ -0,0 +1,150 @@
+package mutate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Parameter classes, see Classify
+const (
+	ClassID     = "id"     // Numeric ids and UUIDs
+	ClassURL    = "url"    // URLs and redirect targets
+	ClassPath   = "path"   // File names and paths
+	ClassEmail  = "email"  // Email addresses
+	ClassJSON   = "json"   // JSON documents
+	ClassBase64 = "base64" // Base64-encoded data
+	ClassBool   = "bool"   // Boolean flags
+	ClassOther  = "other"  // Everything else
+)
+
+// AllClasses lists the parameter classes
+var AllClasses = []string{ClassID, ClassURL, ClassPath, ClassEmail, ClassJSON, ClassBase64, ClassBool, ClassOther}
+
+var (
+	reUUID      = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	reEmail     = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[A-Za-z]{2,}$`)
+	reFilePath  = regexp.MustCompile(`^(?:\.{0,2}/|[A-Za-z]:\\|\.\.\\)|[/\\][^/\\]*\.[A-Za-z][A-Za-z0-9]{0,4}$`)
+	reFileName  = regexp.MustCompile(`^[\w-]+\.([A-Za-z0-9]{2,5})$`)
+	reBase64    = regexp.MustCompile(`^[A-Za-z0-9+/_-]+={0,2}$`)
+	reIDName    = regexp.MustCompile(`^(?i:id|ids|uid|pid|num|no|number|page|offset|limit|count)$|(?i:[_-]ids?)$|[a-z]Ids?$`)
+	reURLName   = regexp.MustCompile(`^(?i:url|uri|link|href|src|next|goto|dest|destination|redir|redirect\w*|return\w*|ret|continue|callback|cb|target|domain|host|site|feed)$|(?i:[_-]ur[il]s?)$|[a-z]Ur[il]s?$`)
+	rePathName  = regexp.MustCompile(`^(?i:file|filename|path|filepath|dir|folder|doc|document|template|tpl|include|inc|load|read|download|attachment|img|image|style|lang|locale|conf|config|log)$|(?i:[_-](?:file|path|dir))$|[a-z](?:File|Path|Dir)$`)
+	reEmailName = regexp.MustCompile(`(?i)^(?:e-?mail|mail)|[_-]e?-?mail$|[a-z]E?[Mm]ail$`)
+	reBoolName  = regexp.MustCompile(`^(?i:is|has|can|show|hide|enable|disable|allow)(?:[_-]\w+|[A-Z]\w*)$|^(?i:debug|admin|test|verbose|active|enabled|disabled)$`)
+	reHex       = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	boolValues  = []string{"true", "false", "yes", "no", "on", "off"}
+	urlPrefixes = []string{"http://", "https://", "//", "ftp://", "file://", "javascript:", "data:"}
+
+	// Extensions making a value without slashes a file name, so that 1.25
+	// or name.asc are not
+	fileExtensions = []string{
+		"txt", "log", "csv", "pdf", "doc", "docx", "xls", "xlsx", "ppt", "pptx", "odt", "rtf",
+		"xml", "json", "yml", "yaml", "ini", "conf", "cfg", "env", "bak", "old", "sql", "db",
+		"html", "htm", "php", "asp", "aspx", "jsp", "jspx", "js", "css", "cgi", "pl", "py", "rb", "sh",
+		"png", "jpg", "jpeg", "gif", "svg", "ico", "webp", "bmp", "mp3", "mp4", "avi", "mov", "wav",
+		"zip", "tar", "gz", "tgz", "rar", "7z", "exe", "dll", "bin",
+	}
+)
+
+// minBase64Size is the length from which a value can be classified as base64
+const minBase64Size = 12
+
+// Classify returns the class of an insertion point from its name and
+// original value. The value decides first, since it is the stronger hint,
+// then the name; percent-encoded values are decoded.
+func Classify(name, value string) string {
+	if decoded, err := url.QueryUnescape(value); err == nil {
+		value = decoded
+	}
+	value = strings.TrimSpace(value)
+	lower := strings.ToLower(value)
+
+	switch {
+	case value == "":
+	case hasAnyPrefix(lower, urlPrefixes):
+		return ClassURL
+	case reEmail.MatchString(value):
+		return ClassEmail
+	case (value[0] == '{' || value[0] == '[') && json.Valid([]byte(value)):
+		return ClassJSON
+	case slices.Contains(boolValues, lower):
+		return ClassBool
+	case isDigits(value) || reUUID.MatchString(value):
+		if (value == "0" || value == "1") && reBoolName.MatchString(name) {
+			return ClassBool
+		}
+		return ClassID
+	case isFilePath(value) && !strings.Contains(value, " "):
+		// A relative URL in a redirect parameter is still a URL
+		if reURLName.MatchString(name) {
+			return ClassURL
+		}
+		return ClassPath
+	case isBase64(value):
+		return ClassBase64
+	}
+
+	switch {
+	case name == "":
+	case reURLName.MatchString(name):
+		return ClassURL
+	case rePathName.MatchString(name):
+		return ClassPath
+	case reEmailName.MatchString(name):
+		return ClassEmail
+	case reBoolName.MatchString(name):
+		return ClassBool
+	case reIDName.MatchString(name):
+		return ClassID
+	}
+	return ClassOther
+}
+
+// isFilePath reports whether s looks like a path, such as ../etc/passwd or
+// a/b.txt, or a file name with a known extension, such as report.pdf
+func isFilePath(s string) bool {
+	if reFilePath.MatchString(s) {
+		return true
+	}
+	m := reFileName.FindStringSubmatch(s)
+	return m != nil && slices.Contains(fileExtensions, strings.ToLower(m[1]))
+}
+
+// isDigits reports whether s is made of ASCII digits only
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isBase64 reports whether s looks like base64 rather than a word or a hex
+// digest: long enough, decodable and mixing letters with digits or symbols
+func isBase64(s string) bool {
+	if len(s) < minBase64Size || !reBase64.MatchString(s) || reHex.MatchString(s) || !strings.ContainsAny(s, "0123456789+/=_-") {
+		return false
+	}
+	trimmed := strings.TrimRight(s, "=")
+	for _, enc := range []*base64.Encoding{base64.RawStdEncoding, base64.RawURLEncoding} {
+		if _, err := enc.DecodeString(trimmed); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyPrefix reports whether s starts with one of prefixes
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package mutate
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+	}{
+		{"id", "42", ClassID},
+		{"user", "123e4567-e89b-12d3-a456-426614174000", ClassID},
+		{"orderId", "", ClassID},
+		{"next", "https://example.com/", ClassURL},
+		{"next", "/home", ClassURL},
+		{"q", "//evil.com", ClassURL},
+		{"file", "a.pdf", ClassPath},
+		{"x", "report.PDF", ClassPath},
+		{"x", "../etc/passwd", ClassPath},
+		{"x", "a/b.txt", ClassPath},
+		{"x", `C:\boot.ini`, ClassPath},
+		{"price", "1.25", ClassOther},
+		{"v", "2.10", ClassOther},
+		{"sort", "name.asc", ClassOther},
+		{"host", "example.com", ClassURL},
+		{"x", "a@example.com", ClassEmail},
+		{"data", `{"a":1}`, ClassJSON},
+		{"token", "dGhpcyBpcyBhIHRlc3Q=", ClassBase64},
+		{"hash", "d41d8cd98f00b204e9800998ecf8427e", ClassOther},
+		{"debug", "1", ClassBool},
+		{"x", "off", ClassBool},
+		{"q", "shoes", ClassOther},
+		{"q", "a%2Fb.txt", ClassPath},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.name, tt.value); got != tt.want {
+			t.Errorf("Classify(%q, %q) = %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
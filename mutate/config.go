@@ -0,0 +1,153 @@
+package mutate
+
+import (
+	"fmt"
+	"iter"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config represents the root structure of the YAML config file
+type Config struct {
+	Extensions     []string        `yaml:"extensions,omitempty"`
+	Headers        []string        `yaml:"headers,omitempty"`
+	AddHeaders     []string        `yaml:"add-headers,omitempty"`
+	Markers        string          `yaml:"markers,omitempty"`
+	JSONValues     string          `yaml:"json-values,omitempty"`
+	XMLDoctype     bool            `yaml:"xml-doctype,omitempty"`
+	Configurations []FuzzingConfig `yaml:"configurations"`
+}
+
+// FuzzingConfig represents a single fuzzing configuration. Payloads and
+// Source, when set, replace the payloads of the Mutator for this
+// configuration; ReadConfig parses Payloads into Source. The lists of Scope
+// that are empty default to those of the Mutator.
+type FuzzingConfig struct {
+	FuzzingPart string           `yaml:"fuzzing-part"`
+	FuzzingType string           `yaml:"fuzzing-type"`
+	FuzzingMode string           `yaml:"fuzzing-mode,omitempty"`
+	Attack      string           `yaml:"attack,omitempty"`
+	Payloads    PayloadList      `yaml:"payloads,omitempty"`
+	Source      iter.Seq[string] `yaml:"-"`
+	Encode      string           `yaml:"encode,omitempty"`
+	Ignore      bool             `yaml:"ignore,omitempty"`
+	Scope       `yaml:",inline"`
+}
+
+// LoadConfig reads a YAML config file and returns its active configurations,
+// skipping the ones marked with ignore: true
+func LoadConfig(configPath string) ([]FuzzingConfig, error) {
+	config, err := ReadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return config.Active(), nil
+}
+
+// ReadConfig reads and validates a YAML config file
+func ReadConfig(configPath string) (*Config, error) {
+	file, err := os.Open(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening config file: %v", err)
+	}
+	defer file.Close()
+
+	var config Config
+	decoder := yaml.NewDecoder(file)
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("error parsing config file: %v", err)
+	}
+
+	if err := config.PartOptions().Validate(); err != nil {
+		return nil, fmt.Errorf("error in config file: %v", err)
+	}
+	for i, cfg := range config.Configurations {
+		if cfg.Ignore {
+			continue
+		}
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("error in config file configuration %d: %v", i+1, err)
+		}
+		if config.Configurations[i].Source, err = cfg.Payloads.Parse(); err != nil {
+			return nil, fmt.Errorf("error in config file configuration %d: %v", i+1, err)
+		}
+	}
+
+	return &config, nil
+}
+
+// PartOptions returns the settings of the built-in parts given at the top
+// level of the config file, for Mutator.Options
+func (c *Config) PartOptions() PartOptions {
+	open, close := ParseMarkers(c.Markers)
+	return PartOptions{
+		Extensions:  c.Extensions,
+		Headers:     c.Headers,
+		AddHeaders:  c.AddHeaders,
+		MarkerOpen:  open,
+		MarkerClose: close,
+		JSONValues:  c.JSONValues,
+		XMLDoctype:  c.XMLDoctype,
+	}
+}
+
+// Active returns the configurations not marked with ignore: true
+func (c *Config) Active() []FuzzingConfig {
+	var activeConfigs []FuzzingConfig
+	for _, cfg := range c.Configurations {
+		if !cfg.Ignore {
+			activeConfigs = append(activeConfigs, cfg)
+		}
+	}
+	return activeConfigs
+}
+
+// Expand returns the configuration once per registered part when its
+// fuzzing-part is "all", or the configuration itself otherwise
+func (c FuzzingConfig) Expand() []FuzzingConfig {
+	if c.FuzzingPart != "all" {
+		return []FuzzingConfig{c}
+	}
+	var configs []FuzzingConfig
+	for _, name := range PartNames() {
+		expanded := c
+		expanded.FuzzingPart = name
+		configs = append(configs, expanded)
+	}
+	return configs
+}
+
+// Validate checks the configuration against the registered fuzzing parts.
+// With fuzzing-part "all" only the type and mode names are checked, since
+// parts that do not support them are skipped. An attack replaces the mode.
+func (c FuzzingConfig) Validate() error {
+	if _, err := ParseEncoders(c.Encode); err != nil {
+		return err
+	}
+	if err := c.Scope.Validate(); err != nil {
+		return err
+	}
+	if c.FuzzingPart == "all" {
+		if !slices.Contains(AllFuzzingTypes, c.FuzzingType) {
+			return fmt.Errorf("%w: %s", ErrInvalidType, c.FuzzingType)
+		}
+		if c.Attack != "" {
+			if !slices.Contains(AllAttacks, c.Attack) {
+				return fmt.Errorf("invalid attack type: %s", c.Attack)
+			}
+			return nil
+		}
+		if !slices.Contains(AllFuzzingModes, c.FuzzingMode) {
+			return fmt.Errorf("%w: %s", ErrUnsupportedMode, c.FuzzingMode)
+		}
+		return nil
+	}
+
+	part, ok := Lookup(c.FuzzingPart)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidPart, c.FuzzingPart)
+	}
+	return checkSupport(part, c.FuzzingType, c.FuzzingMode, c.Attack)
+}
@@ -0,0 +1,95 @@
+package mutate
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+)
+
+// Deduplication modes
+const (
+	DedupeNone  = "none"  // Keep every variant
+	DedupeExact = "exact" // Drop variants that were already generated
+	DedupeShape = "shape" // Also skip inputs with an already seen shape
+)
+
+// Deduper suppresses duplicate variants across configurations, payloads and
+// inputs. Outputs are remembered by their 128-bit FNV-1a hash, so that
+// memory does not grow with the size of the variants, but it still grows
+// with their number. It is not safe for concurrent use.
+type Deduper struct {
+	mode        string
+	skipOutputs bool
+	outputs     map[[16]byte]struct{}
+	shapes      map[string]struct{}
+}
+
+// NewDeduper returns a Deduper for one of DedupeNone, DedupeExact or DedupeShape
+func NewDeduper(mode string) (*Deduper, error) {
+	switch mode {
+	case DedupeNone, DedupeExact, DedupeShape:
+	default:
+		return nil, fmt.Errorf("invalid dedupe mode: %s", mode)
+	}
+	return &Deduper{mode: mode, outputs: make(map[[16]byte]struct{}), shapes: make(map[string]struct{})}, nil
+}
+
+// SeenInput reports whether an input with the same shape as url was already
+// seen. It always reports false unless the mode is DedupeShape.
+func (d *Deduper) SeenInput(url string) bool {
+	if d.mode != DedupeShape {
+		return false
+	}
+	return seen(d.shapes, Shape(url))
+}
+
+// SkipOutputs stops d from remembering outputs, keeping the shape dedupe of
+// inputs. It is meant for generated payloads, whose outputs are too many
+// to remember one hash each.
+func (d *Deduper) SkipOutputs() {
+	d.skipOutputs = true
+}
+
+// SeenOutput reports whether value was already generated. It always reports
+// false in DedupeNone mode and after SkipOutputs.
+func (d *Deduper) SeenOutput(value string) bool {
+	if d.mode == DedupeNone || d.skipOutputs {
+		return false
+	}
+	h := fnv.New128a()
+	h.Write([]byte(value))
+	var key [16]byte
+	h.Sum(key[:0])
+	return seen(d.outputs, key)
+}
+
+// seen records key in set and reports whether it was already there
+func seen[K comparable](set map[K]struct{}, key K) bool {
+	if _, ok := set[key]; ok {
+		return true
+	}
+	set[key] = struct{}{}
+	return false
+}
+
+// Shape returns the host, path and sorted parameter names of url, so that
+// "http://a.com/p?id=1&x=2" and "https://A.com/p?x=3&id=4" share a shape.
+// Targets that are not URLs are their own shape.
+func Shape(url string) string {
+	l, ok := parseURL(url)
+	if !ok {
+		return url
+	}
+
+	var names []string
+	if l.QueryStart >= 0 {
+		for _, p := range parseQuery(url, l.QueryStart, l.QueryEnd) {
+			names = append(names, url[p.NameStart:p.NameEnd])
+		}
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	return strings.ToLower(url[l.HostStart:l.HostEnd]) + url[l.PathStart:l.PathEnd] + "?" + strings.Join(names, "&")
+}
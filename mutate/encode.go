@@ -0,0 +1,190 @@
+package mutate
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// EncodeNone inserts payloads verbatim, without the context encoding of
+// insertion points such as query values and JSON strings
+const EncodeNone = "none"
+
+// Encoders maps encoder names to their function. Encoders are chained in
+// the order given, before the context encoding of the insertion point.
+var Encoders = map[string]func(string) string{
+	"url":         urlEncode,
+	"double-url":  func(s string) string { return urlEncode(urlEncode(s)) },
+	"unicode":     func(s string) string { return escapeRunes(s, "%%u%04X") },
+	"html-entity": htmlEntityEncode,
+	"base64":      func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"hex":         func(s string) string { return hex.EncodeToString([]byte(s)) },
+	"utf-16":      func(s string) string { return escapeRunes(s, `\u%04x`) },
+	"case-swap":   caseSwap,
+}
+
+// AllEncoders lists the encoder names
+var AllEncoders = []string{"url", "double-url", "unicode", "html-entity", "base64", "hex", "utf-16", "case-swap"}
+
+// ParseEncoders splits a comma-separated encoder chain such as "url,base64"
+// and checks the names. "none" cannot be chained.
+func ParseEncoders(chain string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(chain, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := Encoders[name]; !ok && name != EncodeNone {
+			return nil, fmt.Errorf("invalid encoder: %s", name)
+		}
+		names = append(names, name)
+	}
+	if len(names) > 1 && slices.Contains(names, EncodeNone) {
+		return nil, fmt.Errorf("encoder %s cannot be chained", EncodeNone)
+	}
+	return names, nil
+}
+
+// Encode applies an encoder chain to payload, leaving template placeholders
+// such as {{original}} as they are. Unknown names are ignored, chains are
+// expected to be checked with ParseEncoders.
+func Encode(chain, payload string) string {
+	names, _ := ParseEncoders(chain)
+	if len(names) == 0 {
+		return payload
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range append(templateRe.FindAllStringIndex(payload, -1), []int{len(payload), len(payload)}) {
+		text := payload[last:m[0]]
+		for _, name := range names {
+			if encode, ok := Encoders[name]; ok {
+				text = encode(text)
+			}
+		}
+		b.WriteString(text)
+		b.WriteString(payload[m[0]:m[1]])
+		last = m[1]
+	}
+	return b.String()
+}
+
+// queryEscape is the context encoding of query and form values: characters
+// that would end the value or be decoded differently are percent-encoded,
+// the rest of the payload is kept readable
+func queryEscape(s string) string {
+	return percentEscape(s, "%&#+ \t\r\n")
+}
+
+// queryNameEscape is queryEscape for parameter names, which also end at '='
+func queryNameEscape(s string) string {
+	return percentEscape(s, "%&#+= \t\r\n")
+}
+
+// cookieEscape is the context encoding of cookie values: the separators of
+// cookies, whitespace and '%' not starting an escape are percent-encoded
+func cookieEscape(s string) string {
+	return percentEscape(s, "%;, \t\r\n")
+}
+
+// cookieNameEscape is cookieEscape for cookie names, which also end at '='
+func cookieNameEscape(s string) string {
+	return percentEscape(s, "%;,= \t\r\n")
+}
+
+// percentEscape percent-encodes the bytes of s found in chars and the
+// non-ASCII bytes. A '%' in chars is only encoded when it does not start an
+// escape sequence, so that payloads that are already encoded stay as they are.
+func percentEscape(s, chars string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x80 && (strings.IndexByte(chars, c) < 0 || (c == '%' && isPercentEscape(s[i:]))) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// isPercentEscape reports whether s starts with a %XX or %uXXXX escape sequence
+func isPercentEscape(s string) bool {
+	if len(s) >= 6 && (s[1] == 'u' || s[1] == 'U') {
+		if _, err := hex.DecodeString(s[2:6]); err == nil {
+			return true
+		}
+	}
+	if len(s) < 3 {
+		return false
+	}
+	_, err := hex.DecodeString(s[1:3])
+	return err == nil
+}
+
+// urlEncode percent-encodes every byte but the unreserved characters
+func urlEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x80 && (isAlphanumeric(rune(c)) || strings.IndexByte("-_.~", c) >= 0) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// htmlEntityEncode writes every character but ASCII letters and digits as
+// a numeric character reference
+func htmlEntityEncode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if isAlphanumeric(r) {
+			b.WriteRune(r)
+			continue
+		}
+		fmt.Fprintf(&b, "&#x%X;", r)
+	}
+	return b.String()
+}
+
+// escapeRunes writes every character but ASCII letters and digits as its
+// UTF-16 code units formatted with format
+func escapeRunes(s, format string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if isAlphanumeric(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+			fmt.Fprintf(&b, format+format, r1, r2)
+		} else {
+			fmt.Fprintf(&b, format, r)
+		}
+	}
+	return b.String()
+}
+
+// caseSwap swaps the case of every letter
+func caseSwap(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// isAlphanumeric reports whether r is an ASCII letter or digit
+func isAlphanumeric(r rune) bool {
+	return r < 0x80 && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
@@ -0,0 +1,168 @@
+package mutate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Output formats of URL variants
+const (
+	FormatURL   = "url"   // The fuzzed URL
+	FormatRaw   = "raw"   // A raw HTTP request built from the URL
+	FormatCurl  = "curl"  // A curl command line
+	FormatJSONL = "jsonl" // A JSON record with url, method, headers and body
+)
+
+// AllFormats lists the supported output formats
+var AllFormats = []string{FormatURL, FormatRaw, FormatCurl, FormatJSONL}
+
+// DefaultUserAgent is the User-Agent of requests built from URLs
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/128.0.0.0 Safari/537.36"
+
+// RequestFromURL returns a GET request for target with a Host header and
+// every default injectable header, so that header parts have values to fuzz:
+// User-Agent is DefaultUserAgent, Referer the URL itself and the others are
+// empty, MutateURLRequest dropping them from the variants that leave them
+// so. The fragment is not part of the request, nor of the Referer.
+func RequestFromURL(target string) (string, error) {
+	return requestFromURL(target, InjectableHeaders)
+}
+
+// requestFromURL is RequestFromURL with the given injectable headers
+func requestFromURL(target string, injectable []string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid URL for a request: %s", target)
+	}
+	l, _ := parseURL(target)
+	end := l.PathEnd
+	if l.QueryStart >= 0 {
+		end = l.QueryEnd
+	}
+	path := target[l.PathStart:end]
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	var b strings.Builder
+	b.WriteString("GET " + path + " HTTP/1.1\r\n")
+	b.WriteString("Host: " + target[l.HostStart:l.HostEnd] + "\r\n")
+	for _, name := range injectable {
+		value := ""
+		switch strings.ToLower(name) {
+		case "user-agent":
+			value = DefaultUserAgent
+		case "referer":
+			value = target[:end]
+		}
+		b.WriteString(name + ": " + value + "\r\n")
+	}
+	b.WriteString("\r\n")
+	return b.String(), nil
+}
+
+// withoutEmptyHeaders returns raw without its header lines that have no
+// value, such as the injectable headers of RequestFromURL a variant did not
+// fuzz
+func withoutEmptyHeaders(raw string) string {
+	req, err := ParseRequest(raw)
+	if err != nil {
+		return raw
+	}
+	var b strings.Builder
+	last := 0
+	for _, h := range req.Headers {
+		if h.Value != "" {
+			continue
+		}
+		end := len(raw)
+		if i := strings.IndexByte(raw[h.LineStart:], '\n'); i >= 0 {
+			end = h.LineStart + i + 1
+		}
+		b.WriteString(raw[last:h.LineStart])
+		last = end
+	}
+	if last == 0 {
+		return raw
+	}
+	b.WriteString(raw[last:])
+	return b.String()
+}
+
+// FormatRequest renders a variant of the request built from base by
+// RequestFromURL. The URL of curl and JSON output keeps the scheme and host of
+// base, the Host header is only kept when it was fuzzed and Content-Length
+// is left to the client.
+func FormatRequest(raw, base, format string) (string, error) {
+	if format == FormatRaw {
+		return raw, nil
+	}
+	if format != FormatCurl && format != FormatJSONL {
+		return "", fmt.Errorf("invalid format: %s", format)
+	}
+
+	req, err := ParseRequest(raw)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %s", base)
+	}
+	l, _ := parseURL(base)
+	authority := base[l.HostStart:l.HostEnd]
+	target := u.Scheme + "://" + authority + req.Target
+
+	var headers []Header
+	for _, h := range req.Headers {
+		if strings.EqualFold(h.Name, "Content-Length") || (strings.EqualFold(h.Name, "Host") && h.Value == authority) {
+			continue
+		}
+		headers = append(headers, h)
+	}
+	body := req.Body()
+
+	if format == FormatCurl {
+		var b strings.Builder
+		b.WriteString("curl")
+		if req.Method != "GET" {
+			b.WriteString(" -X " + shellQuote(req.Method))
+		}
+		b.WriteString(" " + shellQuote(target))
+		for _, h := range headers {
+			b.WriteString(" -H " + shellQuote(h.Name+": "+h.Value))
+		}
+		if body != "" {
+			b.WriteString(" --data-raw " + shellQuote(body))
+		}
+		return b.String(), nil
+	}
+
+	record := struct {
+		URL     string            `json:"url"`
+		Method  string            `json:"method"`
+		Headers map[string]string `json:"headers"`
+		Body    string            `json:"body,omitempty"`
+	}{URL: target, Method: req.Method, Headers: make(map[string]string), Body: body}
+	for _, h := range headers {
+		if v, ok := record.Headers[h.Name]; ok {
+			record.Headers[h.Name] = v + ", " + h.Value
+		} else {
+			record.Headers[h.Name] = h.Value
+		}
+	}
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(record); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
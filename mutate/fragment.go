@@ -0,0 +1,35 @@
+package mutate
+
+import "strings"
+
+// fragmentSpans locates the whole URL fragment, URLs without a fragment
+// getting one appended
+func fragmentSpans(target string) []Span {
+	l, ok := parseURL(target)
+	if !ok {
+		return nil
+	}
+	if l.FragStart < 0 {
+		return []Span{{Start: len(target), End: len(target), Wrap: func(v string) string { return "#" + v }}}
+	}
+	return []Span{{Start: l.FragStart, End: l.FragEnd}}
+}
+
+// fragmentParamSpans locates the values of the parameters of hash-routed
+// fragments such as "#/route?x=1&y=2" and key=value fragments such as
+// "#x=1&y=2"
+func fragmentParamSpans(target string) []Span {
+	l, ok := parseURL(target)
+	if !ok || l.FragStart < 0 {
+		return nil
+	}
+
+	start := l.FragStart
+	fragment := target[l.FragStart:l.FragEnd]
+	if i := strings.IndexByte(fragment, '?'); i >= 0 {
+		start += i + 1
+	} else if !strings.Contains(fragment, "=") {
+		return nil
+	}
+	return pairValueSpans(target, start, l.FragEnd)
+}
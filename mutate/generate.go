@@ -0,0 +1,218 @@
+package mutate
+
+import (
+	"crypto/rand"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// generators maps the prefix of generated payloads to the function that
+// checks the rest of the spec and returns the payloads as a lazy sequence
+var generators = map[string]func(spec string) (iter.Seq[string], error){
+	"range": rangePayloads,
+	"chars": charsPayloads,
+	"date":  datePayloads,
+	"uuid":  uuidPayloads,
+}
+
+// generatedPayloads returns the payloads of item when it is a generator
+// such as range:1-100, and false when it is a plain payload
+func generatedPayloads(item string) (iter.Seq[string], bool, error) {
+	prefix, spec, ok := strings.Cut(strings.TrimSpace(item), ":")
+	generate, found := generators[prefix]
+	if !ok || !found {
+		return nil, false, nil
+	}
+	seq, err := generate(spec)
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid %s generator %q: %v", prefix, item, err)
+	}
+	return seq, true, nil
+}
+
+// rangePayloads generates the numbers of "start-end[:step]", counting down
+// when end is lower than start. Numbers are zero-padded to the width of
+// start when it has a leading zero, like 001-100.
+func rangePayloads(spec string) (iter.Seq[string], error) {
+	bounds, stepSpec, hasStep := strings.Cut(spec, ":")
+	from, to, ok := strings.Cut(bounds, "-")
+	if !ok {
+		return nil, fmt.Errorf("expected start-end")
+	}
+	start, err := strconv.Atoi(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start: %s", from)
+	}
+	end, err := strconv.Atoi(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end: %s", to)
+	}
+	step := 1
+	if hasStep {
+		if step, err = strconv.Atoi(stepSpec); err != nil || step <= 0 {
+			return nil, fmt.Errorf("invalid step: %s", stepSpec)
+		}
+	}
+	if end < start {
+		step = -step
+	}
+	width := 0
+	if len(from) > 1 && from[0] == '0' {
+		width = len(from)
+	}
+
+	return func(yield func(string) bool) {
+		for n := start; (step > 0 && n <= end) || (step < 0 && n >= end); n += step {
+			if !yield(fmt.Sprintf("%0*d", width, n)) {
+				return
+			}
+		}
+	}, nil
+}
+
+// charsPayloads generates every string of "set[:len=n]" or "set:len=min-max"
+// in order, the set being characters and ranges such as a-z0-9_
+func charsPayloads(spec string) (iter.Seq[string], error) {
+	setSpec, option, _ := strings.Cut(spec, ":")
+	set, err := parseCharSet(setSpec)
+	if err != nil {
+		return nil, err
+	}
+	minLen, maxLen := 1, 1
+	if option != "" {
+		value, ok := strings.CutPrefix(option, "len=")
+		if !ok {
+			return nil, fmt.Errorf("invalid option: %s", option)
+		}
+		from, to, isRange := strings.Cut(value, "-")
+		if !isRange {
+			to = from
+		}
+		minLen, err = strconv.Atoi(from)
+		if err == nil {
+			maxLen, err = strconv.Atoi(to)
+		}
+		if err != nil || minLen < 1 || maxLen < minLen {
+			return nil, fmt.Errorf("invalid length: %s", value)
+		}
+	}
+
+	return func(yield func(string) bool) {
+		for n := minLen; n <= maxLen; n++ {
+			// Odometer over the set, the last character changing fastest
+			indexes := make([]int, n)
+			word := make([]rune, n)
+			for {
+				for i, j := range indexes {
+					word[i] = set[j]
+				}
+				if !yield(string(word)) {
+					return
+				}
+
+				i := n - 1
+				for ; i >= 0; i-- {
+					indexes[i]++
+					if indexes[i] < len(set) {
+						break
+					}
+					indexes[i] = 0
+				}
+				if i < 0 {
+					break
+				}
+			}
+		}
+	}, nil
+}
+
+// parseCharSet expands a character set such as a-zA-Z0-9_ into its
+// characters, without duplicates. A '-' at either end is literal.
+func parseCharSet(spec string) ([]rune, error) {
+	runes := []rune(spec)
+	if len(runes) == 0 {
+		return nil, fmt.Errorf("empty character set")
+	}
+	var set []rune
+	seen := make(map[rune]bool)
+	add := func(r rune) {
+		if !seen[r] {
+			seen[r] = true
+			set = append(set, r)
+		}
+	}
+	for i := 0; i < len(runes); i++ {
+		if i+2 < len(runes) && runes[i+1] == '-' {
+			if runes[i+2] < runes[i] {
+				return nil, fmt.Errorf("invalid character range: %c-%c", runes[i], runes[i+2])
+			}
+			for r := runes[i]; r <= runes[i+2]; r++ {
+				add(r)
+			}
+			i += 2
+			continue
+		}
+		add(runes[i])
+	}
+	return set, nil
+}
+
+// datePayloads generates every day of "start..end[:format=layout]", dates
+// being YYYY-MM-DD and layout a Go time layout such as 20060102
+func datePayloads(spec string) (iter.Seq[string], error) {
+	bounds, option, _ := strings.Cut(spec, ":")
+	from, to, ok := strings.Cut(bounds, "..")
+	if !ok {
+		return nil, fmt.Errorf("expected start..end")
+	}
+	start, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start: %s", from)
+	}
+	end, err := time.Parse(time.DateOnly, to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end: %s", to)
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("end is before start")
+	}
+	layout := time.DateOnly
+	if option != "" {
+		value, ok := strings.CutPrefix(option, "format=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid option: %s", option)
+		}
+		layout = value
+	}
+
+	return func(yield func(string) bool) {
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			if !yield(day.Format(layout)) {
+				return
+			}
+		}
+	}, nil
+}
+
+// uuidPayloads generates the given number of random version 4 UUIDs
+func uuidPayloads(spec string) (iter.Seq[string], error) {
+	n, err := strconv.Atoi(spec)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid count: %s", spec)
+	}
+
+	return func(yield func(string) bool) {
+		for i := 0; i < n; i++ {
+			var b [16]byte
+			rand.Read(b[:])
+			b[6] = b[6]&0x0f | 0x40
+			b[8] = b[8]&0x3f | 0x80
+			if !yield(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])) {
+				return
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,203 @@
+package mutate
+
+import (
+	"slices"
+	"strings"
+)
+
+// InjectableHeaders lists the headers fuzzed by the headers part by default
+var InjectableHeaders = []string{"User-Agent", "Referer", "Cookie", "X-Forwarded-For", "X-Real-IP"}
+
+// AddedHeaders lists the headers inserted by the add-headers part by default
+var AddedHeaders = []string{"X-Forwarded-Host", "X-Original-URL", "X-Rewrite-URL", "True-Client-IP", "X-Client-IP", "X-Forwarded-For"}
+
+// HeaderParts lists the parts fuzzing request headers, which reach URLs
+// through the request RequestFromURL builds
+var HeaderParts = []string{"headers", "add-headers", "cookie-value", "cookie-name", "header-param"}
+
+// headerSpans returns a locator for the value of every injectable header of
+// target, which is a single header line or a block of header lines
+func headerSpans(injectable []string) func(target string) []Span {
+	return func(target string) []Span {
+		var spans []Span
+		forEachHeader(target, 0, func(name string, valueStart, valueEnd int) {
+			if isInjectableHeader(injectable, name) {
+				spans = append(spans, Span{Start: valueStart, End: valueEnd, Name: name})
+			}
+		})
+		return spans
+	}
+}
+
+// rawHeaderSpans returns a raw locator for the value of every injectable
+// header of a raw request that is not ignored
+func rawHeaderSpans(injectable []string) func(req *Request) []Span {
+	return func(req *Request) []Span {
+		var spans []Span
+		for _, h := range req.Headers {
+			if isInjectableHeader(injectable, h.Name) && !req.Ignored(h) {
+				spans = append(spans, Span{Start: h.ValueStart, End: h.ValueEnd, Name: h.Name})
+			}
+		}
+		return spans
+	}
+}
+
+// defaultRawSpans locates what raw requests fuzz without configurations:
+// the end of every injectable header, where the payload is appended, and
+// the parameter values of the target and form body, which it replaces
+func defaultRawSpans(req *Request, injectable []string) []Span {
+	var spans []Span
+	for _, h := range req.Headers {
+		if isInjectableHeader(injectable, h.Name) && !req.Ignored(h) {
+			spans = append(spans, Span{Start: h.ValueEnd, End: h.ValueEnd, Name: h.Name})
+		}
+	}
+	return append(spans, rawParamValueSpans(req)...)
+}
+
+// headerParam is a name=value parameter of a header value, such as a cookie
+// or q=0.9, quoted values without their quotes
+type headerParam struct {
+	NameStart, NameEnd   int
+	ValueStart, ValueEnd int
+}
+
+// parseHeaderParams returns the name=value parameters of the header value
+// s[start:end], parameters being separated by any of seps. Items without
+// "=", like the media type of a Content-Type, are skipped.
+func parseHeaderParams(s string, start, end int, seps string) []headerParam {
+	var params []headerParam
+	for pos := start; pos < end; {
+		stop := strings.IndexAny(s[pos:end], seps+"=")
+		if stop < 0 {
+			break
+		}
+		if s[pos+stop] != '=' {
+			pos += stop + 1
+			continue
+		}
+
+		p := headerParam{NameStart: pos, NameEnd: pos + stop}
+		for p.NameStart < p.NameEnd && strings.IndexByte(" \t", s[p.NameStart]) >= 0 {
+			p.NameStart++
+		}
+		p.NameEnd = p.NameStart + len(strings.TrimRight(s[p.NameStart:p.NameEnd], " \t"))
+
+		pos += stop + 1
+		for pos < end && strings.IndexByte(" \t", s[pos]) >= 0 {
+			pos++
+		}
+		if pos < end && s[pos] == '"' {
+			p.ValueStart = pos + 1
+			p.ValueEnd = p.ValueStart
+			for p.ValueEnd < end && s[p.ValueEnd] != '"' {
+				if s[p.ValueEnd] == '\\' {
+					p.ValueEnd++
+				}
+				p.ValueEnd++
+			}
+			p.ValueEnd = min(p.ValueEnd, end)
+			pos = min(p.ValueEnd+1, end)
+		} else {
+			p.ValueStart = pos
+			if i := strings.IndexAny(s[pos:end], seps); i >= 0 {
+				pos += i
+			} else {
+				pos = end
+			}
+			p.ValueEnd = p.ValueStart + len(strings.TrimRight(s[p.ValueStart:pos], " \t"))
+		}
+		if p.NameEnd > p.NameStart {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// cookieSpans returns a raw locator for the values, or the names, of every
+// cookie of the Cookie headers that are not ignored
+func cookieSpans(names bool) func(req *Request) []Span {
+	return func(req *Request) []Span {
+		var spans []Span
+		for _, h := range req.Headers {
+			if !strings.EqualFold(h.Name, "Cookie") || req.Ignored(h) {
+				continue
+			}
+			for _, p := range parseHeaderParams(req.Raw, h.ValueStart, h.ValueEnd, ";") {
+				name := req.Raw[p.NameStart:p.NameEnd]
+				if names {
+					spans = append(spans, Span{Start: p.NameStart, End: p.NameEnd, Encode: cookieNameEscape, Name: name})
+				} else {
+					spans = append(spans, Span{Start: p.ValueStart, End: p.ValueEnd, Encode: cookieEscape, Name: name})
+				}
+			}
+		}
+		return spans
+	}
+}
+
+// headerParamSpans locates the value of every name=value parameter of the
+// headers other than Cookie that are not ignored, such as the charset of a
+// Content-Type or the fields of a Digest Authorization
+func headerParamSpans(req *Request) []Span {
+	var spans []Span
+	for _, h := range req.Headers {
+		if strings.EqualFold(h.Name, "Cookie") || req.Ignored(h) {
+			continue
+		}
+		for _, p := range parseHeaderParams(req.Raw, h.ValueStart, h.ValueEnd, ";,") {
+			spans = append(spans, Span{Start: p.ValueStart, End: p.ValueEnd, Name: req.Raw[p.NameStart:p.NameEnd]})
+		}
+	}
+	return spans
+}
+
+// addHeaderSpans returns a raw locator with an insertion point after the
+// last header for every added header missing from the request, the payload
+// becoming its value
+func addHeaderSpans(added []string) func(req *Request) []Span {
+	return func(req *Request) []Span {
+		end := req.headersEnd()
+		var spans []Span
+		for _, name := range added {
+			if _, found := req.Header(name); found {
+				continue
+			}
+			prefix, suffix := name+": ", req.lineBreak()
+			if end > 0 && req.Raw[end-1] != '\n' {
+				prefix, suffix = suffix+prefix, ""
+			}
+			spans = append(spans, Span{Start: end, End: end, Wrap: func(v string) string { return prefix + v + suffix }, Name: name})
+		}
+		return spans
+	}
+}
+
+// isInjectableHeader reports whether name is one of the injectable headers
+func isInjectableHeader(injectable []string, name string) bool {
+	return slices.ContainsFunc(injectable, func(h string) bool { return strings.EqualFold(h, name) })
+}
+
+// forEachHeader calls fn for every "Name: value" line of text from offset
+// start up to the first empty line, with the byte offsets of the value
+// without surrounding whitespace
+func forEachHeader(text string, start int, fn func(name string, valueStart, valueEnd int)) {
+	for pos := start; pos < len(text); {
+		lineEnd := len(text)
+		if i := strings.IndexByte(text[pos:], '\n'); i >= 0 {
+			lineEnd = pos + i
+		}
+		line := strings.TrimRight(text[pos:lineEnd], "\r")
+		if line == "" {
+			return
+		}
+
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			valueStart := pos + len(name) + 1 + len(value) - len(strings.TrimLeft(value, " \t"))
+			valueEnd := max(valueStart, pos+len(strings.TrimRight(line, " \t")))
+			fn(strings.TrimSpace(name), valueStart, valueEnd)
+		}
+		pos = lineEnd + 1
+	}
+}
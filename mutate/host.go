@@ -0,0 +1,152 @@
+package mutate
+
+import (
+	"net"
+	"strings"
+)
+
+// hostPart fuzzes the authority of a URL, or the Host header of a raw
+// request together with the authority of an absolute-form request target
+type hostPart struct{}
+
+func (hostPart) Name() string    { return "host" }
+func (hostPart) Types() []string { return AllFuzzingTypes }
+func (hostPart) Modes() []string { return AllFuzzingModes }
+
+// Locate returns the insertion points fuzzed in multiple mode: every
+// subdomain label, or the hostname when it has none, and the port when the
+// authority has one
+func (hostPart) Locate(target string) []Span {
+	l, ok := parseURL(target)
+	if !ok || l.HostStart == l.HostEnd {
+		return nil
+	}
+	return parseHost(target, l.HostStart, l.HostEnd).multiple()
+}
+
+func (hostPart) Apply(target, payload, ftype, mode string) ([]string, error) {
+	l, ok := parseURL(target)
+	if !ok || l.HostStart == l.HostEnd {
+		return ApplySpans(target, nil, payload, ftype, mode)
+	}
+	return parseHost(target, l.HostStart, l.HostEnd).apply(target, payload, ftype, mode)
+}
+
+// LocateRaw returns the multiple mode insertion points of the Host header
+func (hostPart) LocateRaw(req *Request) []Span {
+	h, ok := rawHost(req)
+	if !ok {
+		return nil
+	}
+	return h.multiple()
+}
+
+// ApplyRaw fuzzes the Host header of a raw request
+func (hostPart) ApplyRaw(req *Request, payload, ftype, mode string) ([]string, error) {
+	h, ok := rawHost(req)
+	if !ok {
+		return ApplySpans(req.Raw, nil, payload, ftype, mode)
+	}
+	return h.apply(req.Raw, payload, ftype, mode)
+}
+
+// hostLayout holds the insertion points of an authority
+type hostLayout struct {
+	Name    Span   // Full hostname
+	Labels  []Span // Subdomain labels, every label but the last two
+	Port    Span   // Port, or an empty span after the hostname when there is none
+	HasPort bool   // Whether the authority has a port, multiple mode adding none
+}
+
+// parseHost splits the authority target[start:end] into hostname, subdomain
+// labels and port. IP addresses have no labels.
+func parseHost(target string, start, end int) hostLayout {
+	authority := target[start:end]
+	nameEnd := len(authority)
+	if strings.HasPrefix(authority, "[") {
+		if i := strings.IndexByte(authority, ']'); i >= 0 {
+			nameEnd = i + 1
+		}
+	} else if i := strings.LastIndexByte(authority, ':'); i >= 0 {
+		nameEnd = i
+	}
+
+	h := hostLayout{Name: Span{Start: start, End: start + nameEnd}}
+	if nameEnd < len(authority) && authority[nameEnd] == ':' {
+		h.Port = Span{Start: start + nameEnd + 1, End: end}
+		h.HasPort = true
+	} else {
+		h.Port = Span{Start: start + nameEnd, End: start + nameEnd, Wrap: func(v string) string { return ":" + v }}
+	}
+
+	name := authority[:nameEnd]
+	if net.ParseIP(strings.Trim(name, "[]")) != nil {
+		return h
+	}
+	labels := strings.Split(name, ".")
+	pos := start
+	for i, label := range labels {
+		if i >= len(labels)-2 {
+			break
+		}
+		h.Labels = append(h.Labels, Span{Start: pos, End: pos + len(label)})
+		pos += len(label) + 1
+	}
+	return h
+}
+
+// single returns every insertion point: the hostname, each label and the port
+func (h hostLayout) single() []Span {
+	spans := []Span{h.Name}
+	spans = append(spans, h.Labels...)
+	return append(spans, h.Port)
+}
+
+// multiple returns the non-overlapping insertion points fuzzed at once, the
+// port only when the authority has one
+func (h hostLayout) multiple() []Span {
+	spans := []Span{h.Name}
+	if len(h.Labels) > 0 {
+		spans = append([]Span(nil), h.Labels...)
+	}
+	if h.HasPort {
+		spans = append(spans, h.Port)
+	}
+	return spans
+}
+
+// apply inserts payload at the single or multiple mode insertion points
+func (h hostLayout) apply(target, payload, ftype, mode string) ([]string, error) {
+	if mode == "single" {
+		return ApplySpans(target, h.single(), payload, ftype, mode)
+	}
+	return ApplySpans(target, h.multiple(), payload, ftype, mode)
+}
+
+// link adds the points of other to the matching points of h
+func (h hostLayout) link(other hostLayout) hostLayout {
+	if len(h.Labels) != len(other.Labels) {
+		return h
+	}
+	h.Name.Also = append(h.Name.Also, other.Name)
+	for i := range h.Labels {
+		h.Labels[i].Also = append(h.Labels[i].Also, other.Labels[i])
+	}
+	h.Port.Also = append(h.Port.Also, other.Port)
+	return h
+}
+
+// rawHost locates the Host header of a raw request and links it to the
+// authority of the request target when it is an absolute URL with the same host
+func rawHost(req *Request) (hostLayout, bool) {
+	header, ok := req.Header("Host")
+	if !ok {
+		return hostLayout{}, false
+	}
+	h := parseHost(req.Raw, header.ValueStart, header.ValueEnd)
+
+	if l, ok := parseURL(req.Target); ok && l.HostEnd > l.HostStart && req.Target[l.HostStart:l.HostEnd] == header.Value {
+		h = h.link(parseHost(req.Raw, req.TargetStart+l.HostStart, req.TargetStart+l.HostEnd))
+	}
+	return h, true
+}
@@ -0,0 +1,161 @@
+package mutate
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// How json-value and json-key insert payloads
+const (
+	JSONValuesKeep   = "keep"   // Strings stay strings with the payload escaped, other leaves get the payload as-is
+	JSONValuesString = "string" // Every leaf becomes a string with the payload escaped
+	JSONValuesRaw    = "raw"    // The payload is inserted verbatim, inside the quotes of strings
+)
+
+// AllJSONValues lists the supported json-values settings
+var AllJSONValues = []string{JSONValuesKeep, JSONValuesString, JSONValuesRaw}
+
+// jsonToken is a key or leaf value of a JSON document, strings without
+// their quotes. Name is the key of the value, or of the array holding it.
+type jsonToken struct {
+	Start, End int
+	String     bool
+	Name       string
+}
+
+// jsonSpans locates the leaf values, or the object keys, of a JSON body in
+// document order
+func jsonSpans(req *Request, setting string, keys bool) []Span {
+	start, end, ok := req.jsonBounds()
+	if !ok {
+		return nil
+	}
+	sc := &jsonScanner{s: req.Raw[:end], pos: start}
+	sc.value("")
+
+	tokens := sc.values
+	if keys {
+		tokens = sc.keys
+	}
+	var spans []Span
+	for _, t := range tokens {
+		span := Span{Start: t.Start, End: t.End, Name: t.Name}
+		switch {
+		case setting == JSONValuesRaw:
+		case t.String:
+			span.Encode = jsonEscape
+		case setting == JSONValuesString:
+			span.Encode = jsonEscape
+			span.Wrap = func(v string) string { return `"` + v + `"` }
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// jsonBounds returns the offsets of a JSON body: any body with a JSON
+// Content-Type, or without Content-Type when it is a JSON object or array
+func (r *Request) jsonBounds() (start, end int, ok bool) {
+	body := strings.TrimRight(r.Body(), " \t\r\n")
+	if te, found := r.Header("Transfer-Encoding"); found && hasChunked(te.Value) {
+		return 0, 0, false
+	}
+	if ct, found := r.Header("Content-Type"); found {
+		if !strings.Contains(strings.ToLower(ct.Value), "json") {
+			return 0, 0, false
+		}
+	} else if trimmed := strings.TrimSpace(body); !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return 0, 0, false
+	}
+	if !json.Valid([]byte(body)) {
+		return 0, 0, false
+	}
+	return r.BodyStart, r.BodyStart + len(body), true
+}
+
+// jsonEscape escapes a payload for use inside a JSON string
+func jsonEscape(payload string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(payload)
+	s := strings.TrimSuffix(b.String(), "\n")
+	return s[1 : len(s)-1]
+}
+
+// jsonScanner collects the keys and leaf values of a valid JSON document
+type jsonScanner struct {
+	s      string
+	pos    int
+	keys   []jsonToken
+	values []jsonToken
+}
+
+func (sc *jsonScanner) space() {
+	for sc.pos < len(sc.s) && strings.IndexByte(" \t\r\n", sc.s[sc.pos]) >= 0 {
+		sc.pos++
+	}
+}
+
+func (sc *jsonScanner) value(name string) {
+	sc.space()
+	switch sc.s[sc.pos] {
+	case '{':
+		sc.pos++
+		for {
+			sc.space()
+			if sc.s[sc.pos] == '}' {
+				sc.pos++
+				return
+			}
+			key := sc.str()
+			key.Name = sc.s[key.Start:key.End]
+			sc.keys = append(sc.keys, key)
+			sc.space()
+			sc.pos++ // ':'
+			sc.value(key.Name)
+			sc.space()
+			if sc.s[sc.pos] == ',' {
+				sc.pos++
+			}
+		}
+	case '[':
+		sc.pos++
+		for {
+			sc.space()
+			if sc.s[sc.pos] == ']' {
+				sc.pos++
+				return
+			}
+			sc.value(name)
+			sc.space()
+			if sc.s[sc.pos] == ',' {
+				sc.pos++
+			}
+		}
+	case '"':
+		value := sc.str()
+		value.Name = name
+		sc.values = append(sc.values, value)
+	default:
+		start := sc.pos
+		for sc.pos < len(sc.s) && strings.IndexByte(",]} \t\r\n", sc.s[sc.pos]) < 0 {
+			sc.pos++
+		}
+		sc.values = append(sc.values, jsonToken{Start: start, End: sc.pos, Name: name})
+	}
+}
+
+// str reads a string and returns its contents
+func (sc *jsonScanner) str() jsonToken {
+	sc.pos++
+	start := sc.pos
+	for sc.s[sc.pos] != '"' {
+		if sc.s[sc.pos] == '\\' {
+			sc.pos++
+		}
+		sc.pos++
+	}
+	sc.pos++
+	return jsonToken{Start: start, End: sc.pos - 1, String: true}
+}
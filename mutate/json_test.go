@@ -0,0 +1,83 @@
+package mutate
+
+import (
+	"slices"
+	"testing"
+)
+
+const jsonRequest = "POST /api HTTP/1.1\r\nHost: x\r\nContent-Type: application/json\r\n\r\n"
+
+func TestJSONSpans(t *testing.T) {
+	body := `{"a": "x\"y", "b": [1, true, null], "c": {"d": "e"}, "f": []}`
+	req, err := ParseRequest(jsonRequest + body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values, names []string
+	for _, span := range jsonSpans(req, JSONValuesKeep, false) {
+		values = append(values, req.Raw[span.Start:span.End])
+		names = append(names, span.Name)
+	}
+	if want := []string{`x\"y`, "1", "true", "null", "e"}; !slices.Equal(values, want) {
+		t.Errorf("values: got %q, want %q", values, want)
+	}
+	if want := []string{"a", "b", "b", "b", "d"}; !slices.Equal(names, want) {
+		t.Errorf("names: got %q, want %q", names, want)
+	}
+
+	var keys []string
+	for _, span := range jsonSpans(req, JSONValuesKeep, true) {
+		keys = append(keys, req.Raw[span.Start:span.End])
+	}
+	if want := []string{"a", "b", "c", "d", "f"}; !slices.Equal(keys, want) {
+		t.Errorf("keys: got %q, want %q", keys, want)
+	}
+}
+
+func TestJSONValues(t *testing.T) {
+	body := `{"s": "v", "n": 1}`
+	tests := []struct {
+		setting string
+		want    []string
+	}{
+		{JSONValuesKeep, []string{`{"s": "a\"b", "n": 1}`, `{"s": "v", "n": a"b}`}},
+		{JSONValuesString, []string{`{"s": "a\"b", "n": 1}`, `{"s": "v", "n": "a\"b"}`}},
+		{JSONValuesRaw, []string{`{"s": "a"b", "n": 1}`, `{"s": "v", "n": a"b}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.setting, func(t *testing.T) {
+			req, err := ParseRequest(jsonRequest + body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ApplySpans(req.Raw, jsonSpans(req, tt.setting, false), `a"b`, "replace", "single")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var bodies []string
+			for _, v := range got {
+				bodies = append(bodies, v[len(jsonRequest):])
+			}
+			if !slices.Equal(bodies, tt.want) {
+				t.Errorf("got %q, want %q", bodies, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONSpansNotJSON(t *testing.T) {
+	for _, raw := range []string{
+		jsonRequest + `{"a": 1`,
+		"POST / HTTP/1.1\r\nHost: x\r\nContent-Type: application/x-www-form-urlencoded\r\n\r\n{\"a\": 1}",
+		"GET / HTTP/1.1\r\nHost: x\r\n\r\n",
+	} {
+		req, err := ParseRequest(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if spans := jsonSpans(req, JSONValuesKeep, false); len(spans) != 0 {
+			t.Errorf("%q: got %d spans, want none", raw, len(spans))
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package mutate
+
+import (
+	"strconv"
+	"strings"
+)
+
+// unchunk returns r with a chunked body decoded and "chunked" removed from
+// Transfer-Encoding, so that variants can be sent with a Content-Length.
+// r is returned unchanged when it is not chunked or cannot be decoded.
+func (r *Request) unchunk() *Request {
+	te, ok := r.Header("Transfer-Encoding")
+	if !ok || !hasChunked(te.Value) {
+		return r
+	}
+	body, ok := decodeChunked(r.Body())
+	if !ok {
+		return r
+	}
+
+	var codings []string
+	for _, c := range strings.Split(te.Value, ",") {
+		if c = strings.TrimSpace(c); c != "" && !strings.EqualFold(c, "chunked") {
+			codings = append(codings, c)
+		}
+	}
+	var b strings.Builder
+	if len(codings) == 0 {
+		b.WriteString(r.Raw[:te.LineStart])
+		b.WriteString(r.Raw[lineEnd(r.Raw, te.ValueEnd):r.BodyStart])
+	} else {
+		b.WriteString(r.Raw[:te.ValueStart])
+		b.WriteString(strings.Join(codings, ", "))
+		b.WriteString(r.Raw[te.ValueEnd:r.BodyStart])
+	}
+	b.WriteString(body)
+
+	unchunked, err := ParseRequest(b.String())
+	if err != nil {
+		return r
+	}
+	unchunked.IgnoreLines = r.IgnoreLines
+	return unchunked
+}
+
+// fixLength sets the Content-Length of variant, a fuzzed version of r, to
+// the length of its body. The header is added when the body is not empty
+// and left alone when the body is still chunked.
+func (r *Request) fixLength(variant string) string {
+	v, err := ParseRequest(variant)
+	if err != nil {
+		return variant
+	}
+	if te, ok := v.Header("Transfer-Encoding"); ok && hasChunked(te.Value) {
+		return variant
+	}
+	length := strconv.Itoa(max(len(v.Body())-r.uncountedBreak(), 0))
+
+	var b strings.Builder
+	last, found := 0, false
+	for _, h := range v.Headers {
+		if strings.EqualFold(h.Name, "Content-Length") {
+			b.WriteString(variant[last:h.ValueStart])
+			b.WriteString(length)
+			last, found = h.ValueEnd, true
+		}
+	}
+	if !found {
+		if v.Body() == "" {
+			return variant
+		}
+		end := v.headersEnd()
+		b.WriteString(variant[:end])
+		b.WriteString("Content-Length: " + length + v.lineBreak())
+		last = end
+	}
+	b.WriteString(variant[last:])
+	return b.String()
+}
+
+// uncountedBreak returns the length of the trailing line break of the body
+// when the Content-Length of r does not match the body with it, as happens
+// when a request is saved by a text editor
+func (r *Request) uncountedBreak() int {
+	body := r.Body()
+	if cl, ok := r.Header("Content-Length"); ok && cl.Value == strconv.Itoa(len(body)) {
+		return 0
+	}
+	if strings.HasSuffix(body, "\r\n") {
+		return 2
+	}
+	if strings.HasSuffix(body, "\n") {
+		return 1
+	}
+	return 0
+}
+
+// headersEnd returns the offset right after the last header line, where
+// new headers are inserted
+func (r *Request) headersEnd() int {
+	if len(r.Headers) == 0 {
+		return lineEnd(r.Raw, 0)
+	}
+	return lineEnd(r.Raw, r.Headers[len(r.Headers)-1].ValueEnd)
+}
+
+// lineBreak returns the line ending used by the request
+func (r *Request) lineBreak() string {
+	if i := strings.IndexByte(r.Raw, '\n'); i > 0 && r.Raw[i-1] == '\r' {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// lineEnd returns the offset right after the line break following pos
+func lineEnd(s string, pos int) int {
+	if i := strings.IndexByte(s[pos:], '\n'); i >= 0 {
+		return pos + i + 1
+	}
+	return len(s)
+}
+
+// hasChunked reports whether a Transfer-Encoding value includes chunked
+func hasChunked(value string) bool {
+	for _, c := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(c), "chunked") {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeChunked decodes a chunked body, dropping chunk extensions and trailers
+func decodeChunked(body string) (string, bool) {
+	var b strings.Builder
+	for {
+		line, rest, ok := strings.Cut(body, "\n")
+		if !ok {
+			return "", false
+		}
+		sizeField, _, _ := strings.Cut(strings.TrimRight(line, "\r"), ";")
+		size, err := strconv.ParseInt(strings.TrimSpace(sizeField), 16, 64)
+		if err != nil || size < 0 || size > int64(len(rest)) {
+			return "", false
+		}
+		if size == 0 {
+			return b.String(), true
+		}
+		b.WriteString(rest[:size])
+		body = strings.TrimPrefix(strings.TrimPrefix(rest[size:], "\r"), "\n")
+	}
+}
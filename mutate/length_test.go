@@ -0,0 +1,116 @@
+package mutate
+
+import "testing"
+
+func TestDecodeChunked(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+		ok             bool
+	}{
+		{"single chunk", "5\r\nhello\r\n0\r\n\r\n", "hello", true},
+		{"several chunks", "3\r\nabc\r\n2\r\nde\r\n0\r\n\r\n", "abcde", true},
+		{"LF line endings", "3\nabc\n0\n\n", "abc", true},
+		{"extension", "3;name=value\r\nabc\r\n0\r\n\r\n", "abc", true},
+		{"trailer", "3\r\nabc\r\n0\r\nX-Trailer: 1\r\n\r\n", "abc", true},
+		{"hex size", "a\r\n0123456789\r\n0\r\n\r\n", "0123456789", true},
+		{"empty", "0\r\n\r\n", "", true},
+		{"size too large", "9\r\nabc\r\n0\r\n\r\n", "", false},
+		{"invalid size", "zz\r\nabc\r\n0\r\n\r\n", "", false},
+		{"no last chunk", "3\r\nabc\r\n", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := decodeChunked(tt.in)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("got %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestUnchunk(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			"chunked only",
+			"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\n3\r\na=1\r\n0\r\n\r\n",
+			"POST / HTTP/1.1\r\nHost: x\r\n\r\na=1",
+		},
+		{
+			"other codings kept",
+			"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: gzip, chunked\r\n\r\n3\r\nabc\r\n0\r\n\r\n",
+			"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: gzip\r\n\r\nabc",
+		},
+		{
+			"invalid body left alone",
+			"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\n",
+			"POST / HTTP/1.1\r\nHost: x\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := ParseRequest(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.unchunk().Raw; got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixLength(t *testing.T) {
+	tests := []struct {
+		name, original, variant, want string
+	}{
+		{
+			"body grows",
+			"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 3\r\n\r\na=1",
+			"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 3\r\n\r\na=FUZZ",
+			"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 6\r\n\r\na=FUZZ",
+		},
+		{
+			"trailing newline not counted",
+			"POST / HTTP/1.1\nHost: x\nContent-Length: 3\n\na=1\n",
+			"POST / HTTP/1.1\nHost: x\nContent-Length: 3\n\na=FUZZ\n",
+			"POST / HTTP/1.1\nHost: x\nContent-Length: 6\n\na=FUZZ\n",
+		},
+		{
+			"trailing newline counted",
+			"POST / HTTP/1.1\nHost: x\nContent-Length: 4\n\na=1\n",
+			"POST / HTTP/1.1\nHost: x\nContent-Length: 4\n\na=FUZZ\n",
+			"POST / HTTP/1.1\nHost: x\nContent-Length: 7\n\na=FUZZ\n",
+		},
+		{
+			"header added",
+			"POST / HTTP/1.1\r\nHost: x\r\n\r\na=1",
+			"POST / HTTP/1.1\r\nHost: x\r\n\r\na=FUZZ",
+			"POST / HTTP/1.1\r\nHost: x\r\nContent-Length: 6\r\n\r\na=FUZZ",
+		},
+		{
+			"no body",
+			"GET /?a=1 HTTP/1.1\r\nHost: x\r\n\r\n",
+			"GET /?a=FUZZ HTTP/1.1\r\nHost: x\r\n\r\n",
+			"GET /?a=FUZZ HTTP/1.1\r\nHost: x\r\n\r\n",
+		},
+		{
+			"still chunked",
+			"POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n0\r\n\r\n",
+			"POST / HTTP/1.1\r\nTransfer-Encoding: chunkedFUZZ, chunked\r\n\r\n0\r\n\r\n",
+			"POST / HTTP/1.1\r\nTransfer-Encoding: chunkedFUZZ, chunked\r\n\r\n0\r\n\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := ParseRequest(tt.original)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.fixLength(tt.variant); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package mutate
+
+import "strings"
+
+// DefaultMarker is the Burp Suite insertion point delimiter
+const DefaultMarker = "§"
+
+// MarkedLocator is implemented by parts whose insertion points are marked in
+// the target itself. LocateMarked returns the target without the marks and
+// the insertion points in it.
+type MarkedLocator interface {
+	LocateMarked(target string) (string, []Span)
+}
+
+// markerPart fuzzes the text between insertion markers, in URLs and raw
+// requests alike
+type markerPart struct {
+	open, close string
+}
+
+// ParseMarkers splits a marker setting into its delimiters: one delimiter
+// used on both sides, or an opening and closing delimiter separated by a space
+func ParseMarkers(s string) (open, close string) {
+	if open, close, ok := strings.Cut(strings.TrimSpace(s), " "); ok {
+		return open, strings.TrimSpace(close)
+	}
+	return strings.TrimSpace(s), strings.TrimSpace(s)
+}
+
+func (markerPart) Name() string    { return "markers" }
+func (markerPart) Types() []string { return AllFuzzingTypes }
+func (markerPart) Modes() []string { return AllFuzzingModes }
+
+// LocateMarked removes the markers from target and returns the marked
+// texts as insertion points. An opening marker without a closing one is
+// kept as-is.
+func (p markerPart) LocateMarked(target string) (string, []Span) {
+	var b strings.Builder
+	var spans []Span
+	rest := target
+	for {
+		i := strings.Index(rest, p.open)
+		if i < 0 {
+			break
+		}
+		j := strings.Index(rest[i+len(p.open):], p.close)
+		if j < 0 {
+			break
+		}
+		b.WriteString(rest[:i])
+		start := b.Len()
+		b.WriteString(rest[i+len(p.open) : i+len(p.open)+j])
+		spans = append(spans, Span{Start: start, End: b.Len()})
+		rest = rest[i+len(p.open)+j+len(p.close):]
+	}
+	b.WriteString(rest)
+	return b.String(), spans
+}
+
+func (p markerPart) Apply(target, payload, ftype, mode string) ([]string, error) {
+	clean, spans := p.LocateMarked(target)
+	return ApplySpans(clean, spans, payload, ftype, mode)
+}
+
+func (p markerPart) ApplyRaw(req *Request, payload, ftype, mode string) ([]string, error) {
+	return p.Apply(req.Raw, payload, ftype, mode)
+}
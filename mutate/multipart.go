@@ -0,0 +1,166 @@
+package mutate
+
+import (
+	"mime"
+	"strings"
+)
+
+// multipartSpans locates, for every part of a multipart/form-data body, the
+// name and filename of its Content-Disposition, its Content-Type and its
+// value. Boundaries are left untouched.
+func multipartSpans(req *Request) []Span {
+	boundary, ok := req.multipartBoundary()
+	if !ok {
+		return nil
+	}
+	s := req.Raw
+	delim := "--" + boundary
+
+	first := delimiterIndex(s, delim, req.BodyStart)
+	if first < 0 {
+		return nil
+	}
+	var spans []Span
+	for pos := first; ; {
+		pos += len(delim)
+		if strings.HasPrefix(s[pos:], "--") {
+			break
+		}
+		pos = lineEnd(s, pos)
+		next := delimiterIndex(s, delim, pos)
+		if next < 0 {
+			break
+		}
+
+		// The line break before the delimiter belongs to the delimiter
+		end := next
+		if end > pos && s[end-1] == '\n' {
+			end--
+			if end > pos && s[end-1] == '\r' {
+				end--
+			}
+		}
+		spans = append(spans, multipartPartSpans(s, pos, end)...)
+		pos = next
+	}
+	return spans
+}
+
+// delimiterIndex returns the offset of the first delimiter line of s from
+// pos, or -1. A delimiter starts a line and is followed by "--" or the end
+// of the line, so that values containing the boundary are not split.
+func delimiterIndex(s, delim string, pos int) int {
+	for {
+		i := strings.Index(s[pos:], delim)
+		if i < 0 {
+			return -1
+		}
+		start := pos + i
+		rest := s[start+len(delim):]
+		lineRest := strings.TrimRight(rest[:lineEnd(rest, 0)], " \t\r\n")
+		if (start == 0 || s[start-1] == '\n') && (lineRest == "" || strings.HasPrefix(rest, "--")) {
+			return start
+		}
+		pos = start + 1
+	}
+}
+
+// multipartPartSpans locates the insertion points of the part s[start:end],
+// headers included, named after the field
+func multipartPartSpans(s string, start, end int) []Span {
+	var spans []Span
+	field := ""
+	pos := start
+	for pos < end {
+		lineStop := min(lineEnd(s, pos), end)
+		line := strings.TrimRight(s[pos:lineStop], "\r\n")
+		if line == "" {
+			pos = lineStop
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			valueStart := pos + len(name) + 1 + len(value) - len(strings.TrimLeft(value, " \t"))
+			valueEnd := max(valueStart, pos+len(strings.TrimRight(line, " \t")))
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "content-disposition":
+				params, name := dispositionSpans(s, valueStart, valueEnd)
+				spans = append(spans, params...)
+				field = name
+			case "content-type":
+				spans = append(spans, Span{Start: valueStart, End: valueEnd})
+			}
+		}
+		pos = lineStop
+	}
+	spans = append(spans, Span{Start: min(pos, end), End: end})
+	for i := range spans {
+		spans[i].Name = field
+	}
+	return spans
+}
+
+// dispositionSpans locates the name and filename parameters of the
+// Content-Disposition value s[start:end], without their quotes, and returns
+// the field name
+func dispositionSpans(s string, start, end int) ([]Span, string) {
+	var spans []Span
+	field := ""
+	for pos := start; pos < end; {
+		eq := strings.IndexAny(s[pos:end], ";=")
+		if eq < 0 {
+			break
+		}
+		if s[pos+eq] == ';' {
+			pos += eq + 1
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(s[pos : pos+eq]))
+		pos += eq + 1
+		for pos < end && s[pos] == ' ' {
+			pos++
+		}
+
+		valueStart, valueEnd := pos, pos
+		if pos < end && s[pos] == '"' {
+			valueStart = pos + 1
+			valueEnd = valueStart
+			for valueEnd < end && s[valueEnd] != '"' {
+				if s[valueEnd] == '\\' {
+					valueEnd++
+				}
+				valueEnd++
+			}
+			valueEnd = min(valueEnd, end)
+			pos = min(valueEnd+1, end)
+		} else {
+			for valueEnd < end && s[valueEnd] != ';' {
+				valueEnd++
+			}
+			valueEnd = valueStart + len(strings.TrimRight(s[valueStart:valueEnd], " \t"))
+			pos = valueEnd
+		}
+		if key == "name" || key == "filename" {
+			spans = append(spans, Span{Start: valueStart, End: valueEnd})
+		}
+		if key == "name" {
+			field = s[valueStart:valueEnd]
+		}
+	}
+	return spans, field
+}
+
+// multipartBoundary returns the boundary of a multipart/form-data body
+func (r *Request) multipartBoundary() (string, bool) {
+	ct, ok := r.Header("Content-Type")
+	if !ok {
+		return "", false
+	}
+	mediaType, params, err := mime.ParseMediaType(ct.Value)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return "", false
+	}
+	if te, found := r.Header("Transfer-Encoding"); found && hasChunked(te.Value) {
+		return "", false
+	}
+	return params["boundary"], true
+}
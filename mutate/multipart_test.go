@@ -0,0 +1,102 @@
+package mutate
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMultipartSpans(t *testing.T) {
+	tests := []struct {
+		name, contentType, body string
+		values, names           []string
+	}{
+		{
+			"fields and file",
+			`multipart/form-data; boundary=XyZ`,
+			"--XyZ\r\nContent-Disposition: form-data; name=\"user\"\r\n\r\nadmin\r\n" +
+				"--XyZ\r\nContent-Disposition: form-data; name=\"file\"; filename=\"a.png\"\r\nContent-Type: image/png\r\n\r\nPNG\r\n" +
+				"--XyZ--\r\n",
+			[]string{"user", "admin", "file", "a.png", "image/png", "PNG"},
+			[]string{"user", "user", "file", "file", "file", "file"},
+		},
+		{
+			"quoted boundary and LF line endings",
+			`multipart/form-data; boundary="a b"`,
+			"--a b\nContent-Disposition: form-data; name=x\n\n1\n--a b--\n",
+			[]string{"x", "1"},
+			[]string{"x", "x"},
+		},
+		{
+			"boundary-like text in a value",
+			`multipart/form-data; boundary=B`,
+			"--B\r\nContent-Disposition: form-data; name=\"t\"\r\n\r\na--B\r\n--Bc\r\n--B--",
+			[]string{"t", "a--B\r\n--Bc"},
+			[]string{"t", "t"},
+		},
+		{
+			"empty value",
+			`multipart/form-data; boundary=B`,
+			"--B\r\nContent-Disposition: form-data; name=\"e\"\r\n\r\n\r\n--B--",
+			[]string{"e", ""},
+			[]string{"e", "e"},
+		},
+		{
+			"no closing delimiter",
+			`multipart/form-data; boundary=B`,
+			"--B\r\nContent-Disposition: form-data; name=\"t\"\r\n\r\nv",
+			nil,
+			nil,
+		},
+		{
+			"not multipart",
+			`application/x-www-form-urlencoded`,
+			"--B\r\nContent-Disposition: form-data; name=\"t\"\r\n\r\nv\r\n--B--",
+			nil,
+			nil,
+		},
+		{
+			"no boundary",
+			`multipart/form-data`,
+			"--B\r\nContent-Disposition: form-data; name=\"t\"\r\n\r\nv\r\n--B--",
+			nil,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := ParseRequest("POST /upload HTTP/1.1\r\nHost: x\r\nContent-Type: " + tt.contentType + "\r\n\r\n" + tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var values, names []string
+			for _, span := range multipartSpans(req) {
+				values = append(values, req.Raw[span.Start:span.End])
+				names = append(names, span.Name)
+			}
+			if !slices.Equal(values, tt.values) {
+				t.Errorf("values: got %q, want %q", values, tt.values)
+			}
+			if !slices.Equal(names, tt.names) {
+				t.Errorf("names: got %q, want %q", names, tt.names)
+			}
+		})
+	}
+}
+
+func TestMultipartBoundaryKept(t *testing.T) {
+	raw := "POST / HTTP/1.1\r\nHost: x\r\nContent-Type: multipart/form-data; boundary=B\r\n\r\n" +
+		"--B\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\n1\r\n--B--\r\n"
+	req, err := ParseRequest(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ApplySpans(req.Raw, multipartSpans(req), "FUZZ", "replace", "multiple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "POST / HTTP/1.1\r\nHost: x\r\nContent-Type: multipart/form-data; boundary=B\r\n\r\n" +
+		"--B\r\nContent-Disposition: form-data; name=\"FUZZ\"\r\n\r\nFUZZ\r\n--B--\r\n"
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,496 @@
+// Package mutate generates fuzzed variants of URLs and Burp Suite raw
+// requests. It is the engine behind the pvreplace command and can be used
+// directly from other Go tools.
+package mutate
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+)
+
+// Errors reported for configurations that cannot be applied
+var (
+	ErrInvalidPart     = errors.New("invalid fuzzing part")
+	ErrInvalidType     = errors.New("invalid fuzzing type")
+	ErrUnsupportedMode = errors.New("unsupported fuzzing mode")
+	ErrWrongTarget     = errors.New("wrong target")
+)
+
+// Variant is a single generated URL or raw request
+type Variant struct {
+	Value    string        // The mutated URL or raw request
+	Payload  string        // The payload inserted into Value, before encoding
+	Payloads []string      // The payload of each insertion point, for attacks
+	Config   FuzzingConfig // The configuration that produced Value, with "all" expanded
+}
+
+// Mutator applies a set of fuzzing configurations and payloads to URLs and
+// raw requests
+type Mutator struct {
+	Payloads    []string         // Payloads to insert, surrounding whitespace is trimmed
+	Source      iter.Seq[string] // Optional payloads generated lazily, used instead of Payloads, see ParsePayloads
+	PayloadSets [][]string       // Optional payload set per insertion point for attacks, defaults to Payloads
+	MaxVariants int              // Optional limit on the variants of a single attack
+	Configs     []FuzzingConfig  // Configurations applied in order to every URL
+	IgnoreLines []string         // Raw request line prefixes that are never fuzzed
+	Dedupe      *Deduper         // Optional, suppresses duplicate inputs and variants
+	KeepLength  bool             // Leaves Content-Length and Transfer-Encoding of raw requests as-is
+	Encode      string           // Encoder chain of configurations without their own, see Encoders
+	Scope       Scope            // Scope of raw requests without configurations, and default scope of configurations
+	Options     PartOptions      // Settings of the built-in parts, such as the extensions of the path parts
+}
+
+// New returns a Mutator for the given payloads and configurations
+func New(payloads []string, configs []FuzzingConfig) *Mutator {
+	return &Mutator{Payloads: payloads, Configs: configs}
+}
+
+// MutateURL returns every variant of url for each payload and configuration.
+// Configurations that cannot be applied are reported in the returned error,
+// which joins one error per failure; the variants of the remaining
+// configurations are still returned.
+func (m *Mutator) MutateURL(url string) ([]Variant, error) {
+	return collect(func(fn func(Variant)) error { return m.EachURL(url, fn) })
+}
+
+// EachURL is MutateURL calling fn with every variant as soon as it is
+// generated, so that generated payloads are streamed without being collected
+func (m *Mutator) EachURL(url string, fn func(Variant)) error {
+	if m.Dedupe != nil && m.Dedupe.SeenInput(url) {
+		return nil
+	}
+	return m.mutate(url, nil, fn)
+}
+
+// MutateURLRequest returns the variants of the request RequestFromURL builds
+// for url, with the injectable headers of m.Options, which lets header parts
+// fuzz URLs. Variant values are raw requests that FormatRequest renders,
+// without the headers left empty.
+func (m *Mutator) MutateURLRequest(url string) ([]Variant, error) {
+	return collect(func(fn func(Variant)) error { return m.EachURLRequest(url, fn) })
+}
+
+// EachURLRequest is MutateURLRequest calling fn with every variant as soon
+// as it is generated
+func (m *Mutator) EachURLRequest(url string, fn func(Variant)) error {
+	if m.Dedupe != nil && m.Dedupe.SeenInput(url) {
+		return nil
+	}
+	request, err := requestFromURL(url, m.Options.headers())
+	if err != nil {
+		return err
+	}
+	return m.EachRaw(request, func(v Variant) {
+		v.Value = withoutEmptyHeaders(v.Value)
+		fn(v)
+	})
+}
+
+// MutateRaw returns the variants of a Burp Suite raw request. Without
+// configurations there is one variant per payload: injectable headers get the
+// payload appended and the parameter values of the request target and form
+// body are replaced, except on header lines starting with one of
+// m.IgnoreLines. With configurations, each one is applied to the request by a
+// part implementing RawApplier. The Content-Length of every variant is
+// updated unless m.KeepLength is set.
+func (m *Mutator) MutateRaw(request string) ([]Variant, error) {
+	return collect(func(fn func(Variant)) error { return m.EachRaw(request, fn) })
+}
+
+// EachRaw is MutateRaw calling fn with every variant as soon as it is
+// generated
+func (m *Mutator) EachRaw(request string, fn func(Variant)) error {
+	req, err := m.parseRequest(request)
+	if err != nil {
+		return err
+	}
+	if len(m.Configs) > 0 {
+		return m.mutate(req.Raw, req, fn)
+	}
+
+	if !m.Scope.allows(req.Raw, req) {
+		return nil
+	}
+	spans, ok := m.Scope.filter(req.Raw, defaultRawSpans(req, m.Options.headers()))
+	if !ok {
+		return nil
+	}
+	if m.Encode == EncodeNone {
+		spans = withoutEncode(spans)
+	}
+	for p := range m.payloads() {
+		payload := strings.TrimSpace(p)
+		value, err := splice(req.Raw, spans, Encode(m.Encode, payload), "replace")
+		if err != nil {
+			return err
+		}
+		if !m.KeepLength {
+			value = req.fixLength(value)
+		}
+		if m.Dedupe != nil && m.Dedupe.SeenOutput(value) {
+			continue
+		}
+		fn(Variant{Value: value, Payload: payload})
+	}
+	return nil
+}
+
+// collect returns the variants a streaming method passes to its callback
+func collect(each func(fn func(Variant)) error) ([]Variant, error) {
+	var variants []Variant
+	err := each(func(v Variant) { variants = append(variants, v) })
+	return variants, err
+}
+
+// CountURL returns the number of variants MutateURL generates for url,
+// before deduplication, without building them when possible
+func (m *Mutator) CountURL(url string) (int, error) {
+	return m.count(url, nil)
+}
+
+// CountURLRequest returns the number of variants MutateURLRequest generates
+// for url, before deduplication
+func (m *Mutator) CountURLRequest(url string) (int, error) {
+	request, err := requestFromURL(url, m.Options.headers())
+	if err != nil {
+		return 0, err
+	}
+	return m.CountRaw(request)
+}
+
+// CountRaw returns the number of variants MutateRaw generates for request,
+// before deduplication, without building them when possible
+func (m *Mutator) CountRaw(request string) (int, error) {
+	req, err := m.parseRequest(request)
+	if err != nil {
+		return 0, err
+	}
+	if len(m.Configs) == 0 {
+		return payloadCount(m.payloads()), nil
+	}
+	return m.count(req.Raw, req)
+}
+
+// parseRequest parses a raw request and attaches the ignored lines of m.
+// Chunked bodies are decoded unless m.KeepLength is set.
+func (m *Mutator) parseRequest(request string) (*Request, error) {
+	req, err := ParseRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing raw request: %v", err)
+	}
+	req.IgnoreLines = m.IgnoreLines
+	if !m.KeepLength {
+		req = req.unchunk()
+	}
+	return req, nil
+}
+
+// mutate applies the configurations of m to a URL, or to the raw request req
+// when it is not nil, target being req.Raw, and calls fn with every variant
+func (m *Mutator) mutate(target string, req *Request, fn func(Variant)) error {
+	var errs []error
+	add := func(v Variant) {
+		if req != nil && !m.KeepLength {
+			v.Value = req.fixLength(v.Value)
+		}
+		if m.Dedupe != nil && m.Dedupe.SeenOutput(v.Value) {
+			return
+		}
+		fn(v)
+	}
+
+	apply := func(payload string, cfg FuzzingConfig) {
+		for _, c := range m.expand(cfg, req != nil) {
+			values, err := m.applyConfig(target, req, payload, c)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, v := range values {
+				add(Variant{Value: v, Payload: payload, Config: c})
+			}
+		}
+	}
+
+	// Fuzzing modes insert one payload at a time, configurations with
+	// their own payloads coming after the others
+	for p := range m.payloads() {
+		payload := strings.TrimSpace(p)
+		for _, cfg := range m.Configs {
+			if cfg.Attack == "" && cfg.Source == nil {
+				apply(payload, cfg)
+			}
+		}
+	}
+	for _, cfg := range m.Configs {
+		if cfg.Attack != "" || cfg.Source == nil {
+			continue
+		}
+		for p := range cfg.Source {
+			apply(strings.TrimSpace(p), cfg)
+		}
+	}
+
+	// Attacks iterate over the payload sets themselves
+	for _, cfg := range m.Configs {
+		if cfg.Attack == "" {
+			continue
+		}
+		for _, c := range m.expand(cfg, req != nil) {
+			base, spans, err := m.locateConfig(target, req, c)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			// The limit is checked before generated payloads are collected
+			count := attackCount(c.Attack, len(spans), m.payloadSetLens(c))
+			if err := checkAttackLimit(c.Attack, count, m.MaxVariants); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			sets := m.payloadSets(c)
+			for _, set := range sets {
+				for i := range set {
+					set[i] = Encode(c.Encode, set[i])
+				}
+			}
+			err = RunAttack(base, spans, sets, c.FuzzingType, c.Attack, m.MaxVariants, func(value string, payloads []string) {
+				add(Variant{Value: value, Payload: payloads[0], Payloads: payloads, Config: c})
+			})
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// count returns the number of variants mutate generates for target
+func (m *Mutator) count(target string, req *Request) (int, error) {
+	total := 0
+	var errs []error
+	for _, cfg := range m.Configs {
+		for _, c := range m.expand(cfg, req != nil) {
+			if c.Attack != "" {
+				_, spans, err := m.locateConfig(target, req, c)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				total = addCount(total, attackCount(c.Attack, len(spans), m.payloadSetLens(c)))
+				continue
+			}
+
+			// Span parts are counted without generating the variants
+			if part, err := m.lookupConfig(c, req != nil); err == nil {
+				if sp, ok := part.(*spanPart); ok {
+					spans, ok := configSpans(target, req, sp, c)
+					switch {
+					case !ok:
+					case c.FuzzingMode == "single":
+						total = addCount(total, mulCount(len(spans), payloadCount(m.configPayloads(c))))
+					default:
+						total = addCount(total, payloadCount(m.configPayloads(c)))
+					}
+					continue
+				}
+			}
+			for p := range m.configPayloads(c) {
+				values, err := m.applyConfig(target, req, strings.TrimSpace(p), c)
+				if err != nil {
+					errs = append(errs, err)
+					break
+				}
+				total = addCount(total, len(values))
+			}
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// expand expands cfg like FuzzingConfig.Expand and gives the configurations
+// without an encoder chain the one of m, and the empty scope lists those of m.
+// "all" skips the parts that cannot fuzz URLs, or raw requests when raw is set.
+func (m *Mutator) expand(cfg FuzzingConfig, raw bool) []FuzzingConfig {
+	var configs []FuzzingConfig
+	for _, c := range cfg.Expand() {
+		if part, ok := lookupPart(c.FuzzingPart, m.Options); ok && cfg.FuzzingPart == "all" && !usable(part, raw) {
+			continue
+		}
+		if c.Encode == "" {
+			c.Encode = m.Encode
+		}
+		c.Scope = c.Scope.withDefaults(m.Scope)
+		configs = append(configs, c)
+	}
+	return configs
+}
+
+// payloads returns the payloads of m, generated by m.Source when set
+func (m *Mutator) payloads() iter.Seq[string] {
+	if m.Source != nil {
+		return m.Source
+	}
+	return slices.Values(m.Payloads)
+}
+
+// configPayloads returns the payloads of cfg, which default to those of m
+func (m *Mutator) configPayloads(cfg FuzzingConfig) iter.Seq[string] {
+	if cfg.Source != nil {
+		return cfg.Source
+	}
+	return m.payloads()
+}
+
+// payloadCount returns the number of payloads of seq, generating them
+func payloadCount(seq iter.Seq[string]) int {
+	n := 0
+	for range seq {
+		n++
+	}
+	return n
+}
+
+// payloadSets returns the trimmed payload sets used by attacks with cfg,
+// which need every payload of a set at once. The payloads of cfg replace
+// the payload sets of m.
+func (m *Mutator) payloadSets(cfg FuzzingConfig) [][]string {
+	sets := m.PayloadSets
+	if cfg.Source != nil {
+		sets = [][]string{slices.Collect(cfg.Source)}
+	} else if len(sets) == 0 {
+		sets = [][]string{slices.Collect(m.payloads())}
+	}
+
+	var trimmed [][]string
+	for _, set := range sets {
+		var t []string
+		for _, p := range set {
+			t = append(t, strings.TrimSpace(p))
+		}
+		trimmed = append(trimmed, t)
+	}
+	return trimmed
+}
+
+// payloadSetLens returns the number of payloads of each set payloadSets
+// returns for cfg, generating them without collecting them
+func (m *Mutator) payloadSetLens(cfg FuzzingConfig) []int {
+	if cfg.Source != nil {
+		return []int{payloadCount(cfg.Source)}
+	}
+	if len(m.PayloadSets) == 0 {
+		return []int{payloadCount(m.payloads())}
+	}
+	lens := make([]int, len(m.PayloadSets))
+	for i, set := range m.PayloadSets {
+		lens[i] = len(set)
+	}
+	return lens
+}
+
+// lookupConfig returns the part named by cfg, built with the options of m,
+// after checking that it can be used with the configuration and target kind
+func (m *Mutator) lookupConfig(cfg FuzzingConfig, raw bool) (FuzzingPart, error) {
+	part, ok := lookupPart(cfg.FuzzingPart, m.Options)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPart, cfg.FuzzingPart)
+	}
+	if !usable(part, raw) {
+		if raw {
+			return nil, fmt.Errorf("%w: fuzzing-part %s cannot be used with raw requests", ErrWrongTarget, cfg.FuzzingPart)
+		}
+		return nil, fmt.Errorf("%w: fuzzing-part %s can only be used with raw requests", ErrWrongTarget, cfg.FuzzingPart)
+	}
+	return part, checkSupport(part, cfg.FuzzingType, cfg.FuzzingMode, cfg.Attack)
+}
+
+// applyConfig inserts payload into a URL, or into the raw request req when
+// it is not nil, with the part named by cfg after encoding it with cfg.Encode
+func (m *Mutator) applyConfig(target string, req *Request, payload string, cfg FuzzingConfig) ([]string, error) {
+	part, err := m.lookupConfig(cfg, req != nil)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Scope.allows(target, req) {
+		return nil, nil
+	}
+	payload = Encode(cfg.Encode, payload)
+
+	// Span parts are applied here, so that their insertion points are
+	// filtered by the scope and verbatim insertion skips their context encoding
+	if sp, ok := part.(*spanPart); ok {
+		spans, ok := configSpans(target, req, sp, cfg)
+		if !ok {
+			return nil, nil
+		}
+		return ApplySpans(target, spans, payload, cfg.FuzzingType, cfg.FuzzingMode)
+	}
+	if req != nil {
+		return part.(RawApplier).ApplyRaw(req, payload, cfg.FuzzingType, cfg.FuzzingMode)
+	}
+	return part.Apply(target, payload, cfg.FuzzingType, cfg.FuzzingMode)
+}
+
+// locateConfig returns the insertion points in scope of the part named by
+// cfg in a URL or raw request, along with the text they refer to, which differs from
+// target for parts implementing MarkedLocator
+func (m *Mutator) locateConfig(target string, req *Request, cfg FuzzingConfig) (string, []Span, error) {
+	part, err := m.lookupConfig(cfg, req != nil)
+	if err != nil {
+		return "", nil, err
+	}
+	base, spans := target, []Span(nil)
+	if l, ok := part.(MarkedLocator); ok {
+		base, spans = l.LocateMarked(target)
+	} else if l, ok := part.(RawLocator); ok && req != nil {
+		spans = l.LocateRaw(req)
+	} else if l, ok := part.(Locator); ok && req == nil {
+		spans = l.Locate(target)
+	} else {
+		return "", nil, fmt.Errorf("%w: fuzzing-part %s has no insertion points for attacks", ErrUnsupportedMode, cfg.FuzzingPart)
+	}
+	spans, ok := cfg.Scope.filter(base, spans)
+	if !ok || !cfg.Scope.allows(target, req) {
+		return base, nil, nil
+	}
+	if cfg.Encode == EncodeNone {
+		spans = withoutEncode(spans)
+	}
+	return base, spans, nil
+}
+
+// configSpans locates the insertion points of a span part in a URL or raw
+// request, filtered by the scope of cfg and without context encoding when
+// cfg inserts payloads verbatim. It reports false when the scope filtered
+// out every insertion point.
+func configSpans(target string, req *Request, sp *spanPart, cfg FuzzingConfig) ([]Span, bool) {
+	if !cfg.Scope.allows(target, req) {
+		return nil, false
+	}
+	var spans []Span
+	if req != nil {
+		spans = sp.LocateRaw(req)
+	} else {
+		spans = sp.Locate(target)
+	}
+	spans, ok := cfg.Scope.filter(target, spans)
+	if cfg.Encode == EncodeNone {
+		spans = withoutEncode(spans)
+	}
+	return spans, ok
+}
+
+// withoutEncode returns spans without their context encoding
+func withoutEncode(spans []Span) []Span {
+	clean := make([]Span, len(spans))
+	for i, span := range spans {
+		span.Encode = nil
+		span.Also = withoutEncode(span.Also)
+		clean[i] = span
+	}
+	return clean
+}
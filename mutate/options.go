@@ -0,0 +1,93 @@
+package mutate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PartOptions holds the settings of the built-in fuzzing parts, so that
+// Mutators with different settings can be used side by side. Empty fields
+// keep the defaults.
+type PartOptions struct {
+	Extensions  []string // File extensions of the path parts, DefaultExtensions by default
+	Headers     []string // Headers fuzzed by the headers part and raw requests, InjectableHeaders by default
+	AddHeaders  []string // Headers inserted by add-headers, AddedHeaders by default
+	MarkerOpen  string   // Opening delimiter of the markers part, DefaultMarker by default
+	MarkerClose string   // Closing delimiter of the markers part, MarkerOpen by default
+	JSONValues  string   // How json-value and json-key insert payloads, JSONValuesKeep by default
+	XMLDoctype  bool     // Makes the DOCTYPE declaration the first insertion point of the xml part
+}
+
+// Validate checks the extensions, header names and json-values setting of o
+func (o PartOptions) Validate() error {
+	for _, ext := range o.Extensions {
+		if strings.TrimPrefix(strings.TrimSpace(ext), ".") == "" {
+			return fmt.Errorf("invalid empty extension")
+		}
+	}
+	for _, names := range [][]string{o.Headers, o.AddHeaders} {
+		for _, name := range names {
+			if name = strings.TrimSpace(name); name == "" || strings.ContainsAny(name, ": \t\r\n") {
+				return fmt.Errorf("invalid header name: %q", name)
+			}
+		}
+	}
+	if o.JSONValues != "" && !slices.Contains(AllJSONValues, o.JSONValues) {
+		return fmt.Errorf("invalid json-values: %s", o.JSONValues)
+	}
+	return nil
+}
+
+// extensions returns the file extensions of the path parts
+func (o PartOptions) extensions() []string {
+	if len(o.Extensions) == 0 {
+		return DefaultExtensions
+	}
+	return o.Extensions
+}
+
+// headers returns the injectable headers
+func (o PartOptions) headers() []string {
+	return headerList(o.Headers, InjectableHeaders)
+}
+
+// addHeaders returns the headers inserted by add-headers
+func (o PartOptions) addHeaders() []string {
+	return headerList(o.AddHeaders, AddedHeaders)
+}
+
+// markers returns the opening and closing delimiters of the markers part
+func (o PartOptions) markers() (open, close string) {
+	open, close = o.MarkerOpen, o.MarkerClose
+	if open == "" {
+		open = DefaultMarker
+	}
+	if close == "" {
+		close = open
+	}
+	return open, close
+}
+
+// jsonValues returns the json-values setting
+func (o PartOptions) jsonValues() string {
+	if o.JSONValues == "" {
+		return JSONValuesKeep
+	}
+	return o.JSONValues
+}
+
+// headerList returns the trimmed header names of names, or def when there
+// are none
+func headerList(names, def []string) []string {
+	var clean []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			clean = append(clean, name)
+		}
+	}
+	if len(clean) == 0 {
+		return def
+	}
+	return clean
+}
@@ -0,0 +1,321 @@
+package mutate
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Fuzzing types and modes understood by the built-in parts
+var (
+	AllFuzzingTypes = []string{"replace", "prefix", "postfix"}
+	AllFuzzingModes = []string{"single", "multiple"}
+)
+
+// FuzzingPart is an insertion point family such as parameter values or file
+// extensions. Parts are registered by name and looked up from configurations.
+type FuzzingPart interface {
+	Name() string    // Name used in fuzzing-part
+	Types() []string // Supported fuzzing types
+	Modes() []string // Supported fuzzing modes
+
+	// Apply returns the variants of target for a single payload. The
+	// Mutator only calls it with a type and mode the part supports.
+	Apply(target, payload, ftype, mode string) ([]string, error)
+}
+
+// Locator is implemented by parts whose insertion points are spans of the
+// target, which lets them share the generic replace/prefix/postfix logic
+type Locator interface {
+	Locate(target string) []Span
+}
+
+// RawLocator is implemented by raw request parts whose insertion points are
+// spans of req.Raw, which lets attack types use them
+type RawLocator interface {
+	LocateRaw(req *Request) []Span
+}
+
+// RawApplier is implemented by parts that can also fuzz Burp Suite raw
+// requests. Like Apply, ApplyRaw returns the variants for a single payload.
+type RawApplier interface {
+	ApplyRaw(req *Request, payload, ftype, mode string) ([]string, error)
+}
+
+// Span is an insertion point, target[Start:End] being the original value
+type Span struct {
+	Start, End int
+	Wrap       func(value string) string   // Optional formatting of the inserted value
+	Encode     func(payload string) string // Optional encoding of the payload before it is inserted
+	Also       []Span                      // Other occurrences fuzzed together with this one
+	Name       string                      // Parameter, header or field name of the insertion point, for {{param}}
+	Index      int                         // 1-based position among the insertion points, for {{index}}
+}
+
+// registeredPart is an entry of the registry. Built-in parts whose insertion
+// points depend on PartOptions are built for the options of each Mutator.
+type registeredPart struct {
+	name  string
+	build func(o PartOptions) FuzzingPart
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []registeredPart
+)
+
+// Register adds a fuzzing part to the registry, replacing any part with the
+// same name. New parts are appended to the list "all" expands to.
+func Register(part FuzzingPart) {
+	register(part.Name(), func(PartOptions) FuzzingPart { return part })
+}
+
+// register adds the part built by build to the registry, like Register
+func register(name string, build func(o PartOptions) FuzzingPart) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for i, p := range registry {
+		if p.name == name {
+			registry[i].build = build
+			return
+		}
+	}
+	registry = append(registry, registeredPart{name: name, build: build})
+}
+
+// Lookup returns the registered fuzzing part with the given name, built with
+// the default options
+func Lookup(name string) (FuzzingPart, bool) {
+	return lookupPart(name, PartOptions{})
+}
+
+// lookupPart returns the registered fuzzing part with the given name, built
+// with the options o
+func lookupPart(name string, o PartOptions) (FuzzingPart, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, p := range registry {
+		if p.name == name {
+			return p.build(o), true
+		}
+	}
+	return nil, false
+}
+
+// Parts returns the registered fuzzing parts in registration order, built
+// with the default options
+func Parts() []FuzzingPart {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var parts []FuzzingPart
+	for _, p := range registry {
+		parts = append(parts, p.build(PartOptions{}))
+	}
+	return parts
+}
+
+// PartNames returns the names of the registered fuzzing parts, which is
+// what fuzzing-part "all" expands to
+func PartNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var names []string
+	for _, p := range registry {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// NewPart returns a fuzzing part that inserts payloads at the spans found by
+// locate. In raw requests, locate is run on the request target.
+func NewPart(name string, types, modes []string, locate func(target string) []Span) FuzzingPart {
+	return &spanPart{name: name, types: types, modes: modes, locate: locate}
+}
+
+// NewRawPart is NewPart with a separate locator for raw requests. A nil
+// locate makes a part that only fuzzes raw requests.
+func NewRawPart(name string, types, modes []string, locate func(target string) []Span, locateRaw func(req *Request) []Span) FuzzingPart {
+	return &spanPart{name: name, types: types, modes: modes, locate: locate, locateRaw: locateRaw}
+}
+
+// spanPart is a FuzzingPart built from span locators
+type spanPart struct {
+	name      string
+	types     []string
+	modes     []string
+	locate    func(target string) []Span
+	locateRaw func(req *Request) []Span
+}
+
+func (p *spanPart) Name() string    { return p.name }
+func (p *spanPart) Types() []string { return p.types }
+func (p *spanPart) Modes() []string { return p.modes }
+
+func (p *spanPart) Locate(target string) []Span {
+	if p.locate == nil {
+		return nil
+	}
+	return p.locate(target)
+}
+
+func (p *spanPart) Apply(target, payload, ftype, mode string) ([]string, error) {
+	if p.locate == nil {
+		return nil, fmt.Errorf("%w: fuzzing-part %s can only be used with raw requests", ErrWrongTarget, p.name)
+	}
+	return ApplySpans(target, p.locate(target), payload, ftype, mode)
+}
+
+// rawOnly reports whether part only fuzzes raw requests
+func rawOnly(part FuzzingPart) bool {
+	sp, ok := part.(*spanPart)
+	return ok && sp.locate == nil
+}
+
+// usable reports whether part can fuzz URLs, or raw requests when raw is set
+func usable(part FuzzingPart, raw bool) bool {
+	if raw {
+		_, ok := part.(RawApplier)
+		return ok
+	}
+	return !rawOnly(part)
+}
+
+func (p *spanPart) LocateRaw(req *Request) []Span {
+	if p.locateRaw != nil {
+		return p.locateRaw(req)
+	}
+	return req.targetSpans(p.locate)
+}
+
+func (p *spanPart) ApplyRaw(req *Request, payload, ftype, mode string) ([]string, error) {
+	return ApplySpans(req.Raw, p.LocateRaw(req), payload, ftype, mode)
+}
+
+// checkSupport reports whether part supports the given fuzzing type and
+// mode. The mode is not used, and not checked, when an attack is given.
+func checkSupport(part FuzzingPart, ftype, mode, attack string) error {
+	if !slices.Contains(part.Types(), ftype) {
+		if len(part.Types()) == 1 {
+			return fmt.Errorf("%w: %s (%s only supports %s)", ErrInvalidType, ftype, part.Name(), part.Types()[0])
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidType, ftype)
+	}
+	if attack != "" {
+		if !slices.Contains(AllAttacks, attack) {
+			return fmt.Errorf("invalid attack type: %s", attack)
+		}
+		return nil
+	}
+	if !slices.Contains(part.Modes(), mode) {
+		return fmt.Errorf("%w: fuzzing-mode %s is not supported by fuzzing-part %s", ErrUnsupportedMode, mode, part.Name())
+	}
+	return nil
+}
+
+// Insert combines a payload with the original value according to the fuzzing type
+func Insert(original, payload, ftype string) (string, error) {
+	switch ftype {
+	case "replace":
+		return payload, nil
+	case "prefix":
+		return payload + original, nil
+	case "postfix":
+		return original + payload, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidType, ftype)
+	}
+}
+
+// ApplySpans inserts payload at the given spans of target. In single mode
+// every span produces its own variant; in multiple mode all spans are
+// fuzzed at once and target is returned unchanged when there is no span.
+func ApplySpans(target string, spans []Span, payload, ftype, mode string) ([]string, error) {
+	spans = numberSpans(spans)
+	switch mode {
+	case "single":
+		var variants []string
+		for _, span := range spans {
+			v, err := splice(target, []Span{span}, payload, ftype)
+			if err != nil {
+				return nil, err
+			}
+			variants = append(variants, v)
+		}
+		return variants, nil
+	case "multiple":
+		v, err := splice(target, spans, payload, ftype)
+		if err != nil {
+			return nil, err
+		}
+		return []string{v}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMode, mode)
+	}
+}
+
+// splice rebuilds target with payload inserted at each of the
+// non-overlapping spans and their linked occurrences
+func splice(target string, spans []Span, payload, ftype string) (string, error) {
+	payloads := make([]string, len(spans))
+	for i := range payloads {
+		payloads[i] = payload
+	}
+	return spliceEach(target, spans, payloads, ftype)
+}
+
+// numberSpans returns spans with their 1-based Index set
+func numberSpans(spans []Span) []Span {
+	numbered := make([]Span, len(spans))
+	for i, span := range spans {
+		span.Index = i + 1
+		numbered[i] = span
+	}
+	return numbered
+}
+
+// spliceEach is splice with a payload per span. Payload templates are
+// expanded after the context encoding of the span.
+func spliceEach(target string, spans []Span, payloads []string, ftype string) (string, error) {
+	type insertion struct {
+		span    Span
+		payload string
+	}
+	var all []insertion
+	for i, span := range spans {
+		if span.Index == 0 {
+			span.Index = i + 1
+		}
+		all = append(all, insertion{span, payloads[i]})
+		for _, also := range span.Also {
+			also.Name, also.Index = span.Name, span.Index
+			all = append(all, insertion{also, payloads[i]})
+		}
+	}
+	slices.SortStableFunc(all, func(a, b insertion) int { return a.span.Start - b.span.Start })
+
+	var out []byte
+	last := 0
+	for _, in := range all {
+		original := target[in.span.Start:in.span.End]
+		if in.span.Encode != nil {
+			in.payload = in.span.Encode(in.payload)
+		}
+		in.payload = expandTemplate(in.payload, target, original, in.span)
+		value, err := Insert(original, in.payload, ftype)
+		if err != nil {
+			return "", err
+		}
+		if in.span.Wrap != nil {
+			value = in.span.Wrap(value)
+		}
+		out = append(out, target[last:in.span.Start]...)
+		out = append(out, value...)
+		last = in.span.End
+	}
+	out = append(out, target[last:]...)
+	return string(out), nil
+}
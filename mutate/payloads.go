@@ -0,0 +1,322 @@
+package mutate
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadPayloads reads payloads from a .txt file or a comma-separated list,
+// generators and payload sources included, see ParsePayloads
+func LoadPayloads(input string) ([]string, error) {
+	seq, err := ParsePayloads(input)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Collect(seq), nil
+}
+
+// ParsePayloads returns the payloads of a .txt file or a comma-separated
+// list as a sequence. "\," is a comma inside a payload. List items can be
+// payload sources:
+//
+//   - file:path or @path, the lines of a file of any name, gzip or not
+//   - dir:path, the lines of every file of a directory, in name order
+//   - "-", the lines of standard input
+//
+// Lines of sources starting with '#' are comments. Items can also be
+// generators, evaluated lazily: range:1-1000[:step], chars:a-z0-9[:len=min-max],
+// date:2024-01-01..2024-12-31[:format=layout] and uuid:count. A leading '\'
+// makes a payload such as \@evil.com or \range:1-2 literal, and file:// URIs
+// are payloads, not sources.
+func ParsePayloads(input string) (iter.Seq[string], error) {
+	if isPayloadFile(input) {
+		lines, err := readPayloadFile(input)
+		if err != nil {
+			return nil, err
+		}
+		return slices.Values(lines), nil
+	}
+
+	return itemPayloads(splitPayloads(input))
+}
+
+// isPayloadFile reports whether input is a single .txt file name, which is
+// read like file:input
+func isPayloadFile(input string) bool {
+	return strings.HasSuffix(input, ".txt") && !strings.ContainsAny(input, ",@") &&
+		!strings.HasPrefix(input, "file:") && !strings.HasPrefix(input, "dir:")
+}
+
+// PayloadList is the payloads of a configuration: in YAML, either a string
+// like the -payload flag or a list of payloads, sources and generators
+type PayloadList []string
+
+// UnmarshalYAML splits a string like ParsePayloads and takes the items of a
+// list as they are
+func (l *PayloadList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if isPayloadFile(node.Value) {
+			*l = PayloadList{"file:" + node.Value}
+		} else {
+			*l = splitPayloads(node.Value)
+		}
+		return nil
+	}
+	var items []string
+	if err := node.Decode(&items); err != nil {
+		return err
+	}
+	*l = items
+	return nil
+}
+
+// Parse returns the payloads of the list, nil when it is empty. Standard
+// input is not a source of configuration payloads, since it may provide the
+// URLs.
+func (l PayloadList) Parse() (iter.Seq[string], error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	for _, item := range l {
+		if strings.TrimSpace(item) == "-" {
+			return nil, fmt.Errorf("payloads cannot be read from stdin in a configuration")
+		}
+	}
+	return itemPayloads(l)
+}
+
+// itemPayloads returns the payloads of a list of payloads, sources and
+// generators
+func itemPayloads(items []string) (iter.Seq[string], error) {
+	var seqs []iter.Seq[string]
+	for _, item := range items {
+		if literal, ok := escapedPayload(item); ok {
+			seqs = append(seqs, slices.Values([]string{literal}))
+			continue
+		}
+		seq, ok, err := sourcePayloads(item)
+		if !ok && err == nil {
+			seq, ok, err = generatedPayloads(item)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			seq = slices.Values([]string{item})
+		}
+		seqs = append(seqs, seq)
+	}
+	return func(yield func(string) bool) {
+		for _, seq := range seqs {
+			for p := range seq {
+				if !yield(p) {
+					return
+				}
+			}
+		}
+	}, nil
+}
+
+// UsesStdin reports whether the payloads of input are read from standard
+// input, which then cannot provide the URLs
+func UsesStdin(input string) bool {
+	return !isPayloadFile(input) && slices.ContainsFunc(splitPayloads(input), func(item string) bool {
+		return strings.TrimSpace(item) == "-"
+	})
+}
+
+// UsesGenerators reports whether the payloads of input include generators
+func UsesGenerators(input string) bool {
+	return !isPayloadFile(input) && usesGenerators(splitPayloads(input))
+}
+
+// UsesGenerators reports whether the list includes generators
+func (l PayloadList) UsesGenerators() bool {
+	return usesGenerators(l)
+}
+
+// usesGenerators reports whether items include generators that are not
+// escaped
+func usesGenerators(items []string) bool {
+	return slices.ContainsFunc(items, func(item string) bool {
+		prefix, _, found := strings.Cut(strings.TrimSpace(item), ":")
+		return found && generators[prefix] != nil
+	})
+}
+
+// splitPayloads splits a comma-separated payload list, "\," being a comma
+// inside a payload
+func splitPayloads(input string) []string {
+	var items []string
+	var b strings.Builder
+	for i := 0; i < len(input); i++ {
+		switch {
+		case input[i] == '\\' && i+1 < len(input) && input[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case input[i] == ',':
+			items = append(items, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(input[i])
+		}
+	}
+	return append(items, b.String())
+}
+
+// escapedPayload returns item without its leading '\' when the rest would
+// otherwise be read as a payload source or generator
+func escapedPayload(item string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(item), `\`)
+	if !ok {
+		return "", false
+	}
+	if rest == "-" || strings.HasPrefix(rest, "@") || strings.HasPrefix(rest, "file:") || strings.HasPrefix(rest, "dir:") {
+		return rest, true
+	}
+	if prefix, _, found := strings.Cut(rest, ":"); found && generators[prefix] != nil {
+		return rest, true
+	}
+	return "", false
+}
+
+// sourcePayloads returns the payloads of item when it names a file,
+// directory or standard input, and false when it does not. file:// URIs
+// are not sources, so that they stay usable as SSRF payloads.
+func sourcePayloads(item string) (iter.Seq[string], bool, error) {
+	item = strings.TrimSpace(item)
+	var lines []string
+	var err error
+	switch {
+	case item == "-":
+		lines, err = readStdinPayloads()
+	case strings.HasPrefix(item, "@") && len(item) > 1:
+		lines, err = readPayloadFile(item[1:])
+	case strings.HasPrefix(item, "file:") && !strings.HasPrefix(item, "file://"):
+		lines, err = readPayloadFile(strings.TrimPrefix(item, "file:"))
+	case strings.HasPrefix(item, "dir:"):
+		lines, err = readPayloadDir(strings.TrimPrefix(item, "dir:"))
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	return slices.Values(lines), true, nil
+}
+
+// readStdinPayloads reads the payloads of standard input once, so that
+// several payload sets can refer to it
+var readStdinPayloads = sync.OnceValues(func() ([]string, error) {
+	lines, err := readPayloadLines(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("error reading payloads from stdin: %v", err)
+	}
+	return lines, nil
+})
+
+// readPayloadFile returns the payloads of a file, decompressing it when it
+// is gzip-compressed
+func readPayloadFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening payload file: %v", err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	var in io.Reader = r
+	if magic, _ := r.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("error reading payload file %s: %v", path, err)
+		}
+		defer gz.Close()
+		in = gz
+	}
+	lines, err := readPayloadLines(in)
+	if err != nil {
+		return nil, fmt.Errorf("error reading payload file %s: %v", path, err)
+	}
+	return lines, nil
+}
+
+// readPayloadDir returns the payloads of every file of a directory, in
+// name order, subdirectories excluded
+func readPayloadDir(path string) ([]string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading payload directory: %v", err)
+	}
+	var lines []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		fileLines, err := readPayloadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, fileLines...)
+	}
+	return lines, nil
+}
+
+// readPayloadLines returns the lines of r that are neither blank nor
+// comments starting with '#'
+func readPayloadLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// LoadIgnoreLines reads raw request line prefixes to ignore from a .txt file
+// or a comma-separated list
+func LoadIgnoreLines(input string) ([]string, error) {
+	return readList(input, "ignore lines file")
+}
+
+// readList returns the non-empty lines of a .txt file, or the comma-separated
+// values of input when it is not a file name
+func readList(input, what string) ([]string, error) {
+	if !strings.HasSuffix(input, ".txt") {
+		return strings.Split(input, ","), nil
+	}
+
+	file, err := os.Open(input)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %s: %v", what, err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", what, err)
+	}
+	return lines, nil
+}
@@ -0,0 +1,160 @@
+package mutate
+
+import (
+	"net/url"
+	"strings"
+)
+
+// urlLayout holds the byte offsets of the components of a URL. Components
+// are located the same way net/url splits them, so everything outside of the
+// component being fuzzed keeps its original bytes and encoding.
+type urlLayout struct {
+	HostStart, HostEnd   int // Authority without userinfo, including the port
+	PathStart, PathEnd   int // Path, including matrix parameters such as ;jsessionid=
+	QueryStart, QueryEnd int // Query without the '?', QueryStart is -1 when there is no query
+	FragStart, FragEnd   int // Fragment without the '#', FragStart is -1 when there is no fragment
+}
+
+// parseURL splits target into its components. It reports false when target
+// is not a valid URL.
+func parseURL(target string) (urlLayout, bool) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return urlLayout{}, false
+	}
+
+	l := urlLayout{QueryStart: -1, QueryEnd: -1, FragStart: -1, FragEnd: -1}
+	rest := target
+	if i := strings.IndexByte(rest, '#'); i >= 0 {
+		l.FragStart, l.FragEnd = i+1, len(target)
+		rest = rest[:i]
+	}
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		l.QueryStart, l.QueryEnd = i+1, len(rest)
+		rest = rest[:i]
+	}
+
+	pos := 0
+	if u.Scheme != "" {
+		pos = len(u.Scheme) + 1
+	}
+	if strings.HasPrefix(rest[pos:], "//") {
+		pos += 2
+		end := len(rest)
+		if i := strings.IndexByte(rest[pos:], '/'); i >= 0 {
+			end = pos + i
+		}
+		l.HostStart, l.HostEnd = pos, end
+		if i := strings.LastIndexByte(rest[pos:end], '@'); i >= 0 {
+			l.HostStart = pos + i + 1
+		}
+		pos = end
+	} else {
+		l.HostStart, l.HostEnd = pos, pos
+	}
+	l.PathStart, l.PathEnd = pos, len(rest)
+	return l, true
+}
+
+// queryParam holds the byte offsets of a name=value pair of a query string
+type queryParam struct {
+	NameStart, NameEnd   int
+	ValueStart, ValueEnd int // ValueStart is -1 for a flag without '='
+}
+
+// parseQuery splits the query string target[start:end] into its pairs in
+// their original order. Empty pairs such as the one in "a=1&&b=2" are skipped.
+func parseQuery(target string, start, end int) []queryParam {
+	var params []queryParam
+	for pos := start; pos <= end; {
+		pairEnd := end
+		if i := strings.IndexByte(target[pos:end], '&'); i >= 0 {
+			pairEnd = pos + i
+		}
+		if pairEnd > pos {
+			p := queryParam{NameStart: pos, NameEnd: pairEnd, ValueStart: -1, ValueEnd: -1}
+			if i := strings.IndexByte(target[pos:pairEnd], '='); i >= 0 {
+				p.NameEnd = pos + i
+				p.ValueStart, p.ValueEnd = pos+i+1, pairEnd
+			}
+			params = append(params, p)
+		}
+		pos = pairEnd + 1
+	}
+	return params
+}
+
+// queryBounds returns the query string of target. A target that is not a
+// URL but looks like a bare query string, such as "a=1&b=2", is a query
+// string as a whole.
+func queryBounds(target string) (start, end int, ok bool) {
+	if l, ok := parseURL(target); ok && l.QueryStart >= 0 {
+		return l.QueryStart, l.QueryEnd, true
+	}
+	if strings.Contains(target, "=") && !strings.ContainsAny(target, "/?# \t") {
+		return 0, len(target), true
+	}
+	return 0, 0, false
+}
+
+// paramValueSpans locates the value of every query parameter
+func paramValueSpans(target string) []Span {
+	start, end, ok := queryBounds(target)
+	if !ok {
+		return nil
+	}
+	return pairValueSpans(target, start, end)
+}
+
+// pairValueSpans locates the value of every pair of the query string
+// target[start:end]. Flags without '=' get one added in front of the
+// inserted value. Payloads are escaped so that they stay within the value.
+func pairValueSpans(target string, start, end int) []Span {
+	var spans []Span
+	for _, p := range parseQuery(target, start, end) {
+		if p.ValueStart < 0 {
+			spans = append(spans, Span{Start: p.NameEnd, End: p.NameEnd, Wrap: func(v string) string { return "=" + v }, Encode: queryEscape, Name: target[p.NameStart:p.NameEnd]})
+			continue
+		}
+		spans = append(spans, Span{Start: p.ValueStart, End: p.ValueEnd, Encode: queryEscape, Name: target[p.NameStart:p.NameEnd]})
+	}
+	return spans
+}
+
+// paramNameSpans locates the name of every query parameter
+func paramNameSpans(target string) []Span {
+	start, end, ok := queryBounds(target)
+	if !ok {
+		return nil
+	}
+	return pairNameSpans(target, start, end)
+}
+
+// pairNameSpans locates the name of every pair of the query string target[start:end]
+func pairNameSpans(target string, start, end int) []Span {
+	var spans []Span
+	for _, p := range parseQuery(target, start, end) {
+		spans = append(spans, Span{Start: p.NameStart, End: p.NameEnd, Encode: queryNameEscape, Name: target[p.NameStart:p.NameEnd]})
+	}
+	return spans
+}
+
+// rawParamValueSpans locates the parameter values of the request target and
+// of a form-urlencoded body
+func rawParamValueSpans(req *Request) []Span {
+	spans := req.targetSpans(paramValueSpans)
+	if start, end, ok := req.formBounds(); ok {
+		spans = append(spans, pairValueSpans(req.Raw, start, end)...)
+	}
+	return spans
+}
+
+// rawParamNameSpans locates the parameter names of the request target and
+// of a form-urlencoded body
+func rawParamNameSpans(req *Request) []Span {
+	spans := req.targetSpans(paramNameSpans)
+	if start, end, ok := req.formBounds(); ok {
+		spans = append(spans, pairNameSpans(req.Raw, start, end)...)
+	}
+	return spans
+}
@@ -0,0 +1,92 @@
+package mutate
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParamValueReplace(t *testing.T) {
+	tests := []struct {
+		name, in string
+		want     []string
+	}{
+		{"matrix parameters", "http://x/a.jsp;jsessionid=1?id=2", []string{"http://x/a.jsp;jsessionid=1?id=FUZZ"}},
+		{"equals in path", "http://x/a=b/page?id=1", []string{"http://x/a=b/page?id=FUZZ"}},
+		{"fragment", "http://x/?id=1#a=b", []string{"http://x/?id=FUZZ#a=b"}},
+		{"base64 value", "http://x/?t=aGk=", []string{"http://x/?t=FUZZ"}},
+		{"flag", "http://x/?flag&id=1", []string{"http://x/?flag=FUZZ&id=FUZZ"}},
+		{"empty pair", "http://x/?a=1&&b=2", []string{"http://x/?a=FUZZ&&b=FUZZ"}},
+		{"encoding kept", "http://x/?r=%20x&id=1", []string{"http://x/?r=FUZZ&id=FUZZ"}},
+		{"bare query", "a=1&b=2", []string{"a=FUZZ&b=FUZZ"}},
+		{"no query", "http://x/a=b", []string{"http://x/a=b"}},
+		{"invalid URL", "http://%zz/?id=1", []string{"http://%zz/?id=1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplySpans(tt.in, paramValueSpans(tt.in), "FUZZ", "replace", "multiple")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParamValueSingle(t *testing.T) {
+	in := "http://x/p;s=1?a=1&&b=2#c=3"
+	got, err := ApplySpans(in, paramValueSpans(in), "FUZZ", "replace", "single")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"http://x/p;s=1?a=FUZZ&&b=2#c=3", "http://x/p;s=1?a=1&&b=FUZZ#c=3"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParamName(t *testing.T) {
+	tests := []struct {
+		name, in string
+		want     []string
+	}{
+		{"pairs", "http://x/?a=1&b=2", []string{"http://x/?FUZZ=1&b=2", "http://x/?a=1&FUZZ=2"}},
+		{"flag", "http://x/?flag#a=b", []string{"http://x/?FUZZ#a=b"}},
+		{"base64 value", "http://x/?t=aGk=", []string{"http://x/?FUZZ=aGk="}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplySpans(tt.in, paramNameSpans(tt.in), "FUZZ", "replace", "single")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryContextEncoding(t *testing.T) {
+	in := "http://x/?q=1"
+	got, err := ApplySpans(in, paramValueSpans(in), "a&b c+d#é%27", "replace", "multiple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "http://x/?q=a%26b%20c%2Bd%23%C3%A9%27"
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSpanNames(t *testing.T) {
+	in := "http://x/?id=1&flag&name=a"
+	var names []string
+	for _, span := range paramValueSpans(in) {
+		names = append(names, span.Name)
+	}
+	if want := []string{"id", "flag", "name"}; !slices.Equal(names, want) {
+		t.Errorf("got %q, want %q", names, want)
+	}
+}
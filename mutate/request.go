@@ -0,0 +1,143 @@
+package mutate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Request is a parsed Burp Suite raw request. Components keep their byte
+// offsets in Raw, so that payloads are spliced into the original text and
+// everything left untouched is serialized back byte for byte.
+type Request struct {
+	Raw         string
+	Method      string
+	Target      string
+	Version     string
+	TargetStart int      // Offset of Target in Raw
+	Headers     []Header // Headers in their original order
+	BodyStart   int      // Offset of the body in Raw, len(Raw) when there is none
+	IgnoreLines []string // Header line prefixes that header parts do not fuzz
+}
+
+// Header is a single header line of a Request
+type Header struct {
+	Name       string
+	Value      string
+	LineStart  int // Offset of the header line in Raw
+	ValueStart int // Offset of Value in Raw, without surrounding whitespace
+	ValueEnd   int
+}
+
+// ParseRequest parses a raw HTTP/1.x request. Both CRLF and LF line endings
+// are accepted.
+func ParseRequest(raw string) (*Request, error) {
+	lineEnd := strings.IndexByte(raw, '\n')
+	if lineEnd < 0 {
+		lineEnd = len(raw)
+	}
+	requestLine := strings.TrimRight(raw[:lineEnd], "\r")
+	fields := strings.Fields(requestLine)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid request line: %q", requestLine)
+	}
+
+	req := &Request{
+		Raw:         raw,
+		Method:      fields[0],
+		Target:      fields[1],
+		TargetStart: len(fields[0]) + strings.Index(requestLine[len(fields[0]):], fields[1]),
+		BodyStart:   len(raw),
+	}
+	if len(fields) > 2 {
+		req.Version = fields[2]
+	}
+
+	for pos := lineEnd + 1; pos < len(raw); {
+		end := len(raw)
+		if i := strings.IndexByte(raw[pos:], '\n'); i >= 0 {
+			end = pos + i
+		}
+		line := strings.TrimRight(raw[pos:end], "\r")
+		if line == "" {
+			req.BodyStart = min(end+1, len(raw))
+			break
+		}
+
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			valueStart := pos + len(name) + 1 + len(value) - len(strings.TrimLeft(value, " \t"))
+			valueEnd := max(valueStart, pos+len(strings.TrimRight(line, " \t")))
+			req.Headers = append(req.Headers, Header{
+				Name:       strings.TrimSpace(name),
+				Value:      raw[valueStart:valueEnd],
+				LineStart:  pos,
+				ValueStart: valueStart,
+				ValueEnd:   valueEnd,
+			})
+		}
+		pos = end + 1
+	}
+	return req, nil
+}
+
+// Header returns the first header with the given name, case-insensitively
+func (r *Request) Header(name string) (Header, bool) {
+	for _, h := range r.Headers {
+		if strings.EqualFold(h.Name, name) {
+			return h, true
+		}
+	}
+	return Header{}, false
+}
+
+// Body returns the request body
+func (r *Request) Body() string {
+	return r.Raw[r.BodyStart:]
+}
+
+// Ignored reports whether the line of h starts with one of r.IgnoreLines
+func (r *Request) Ignored(h Header) bool {
+	line := r.Raw[h.LineStart:h.ValueEnd]
+	for _, prefix := range r.IgnoreLines {
+		if prefix = strings.TrimSpace(prefix); prefix != "" && strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// targetSpans runs a URL locator on the request target and returns the
+// spans as offsets in Raw
+func (r *Request) targetSpans(locate func(string) []Span) []Span {
+	return shiftSpans(locate(r.Target), r.TargetStart)
+}
+
+// formBounds returns the offsets of a form-urlencoded body without its
+// trailing line break. Bodies without Content-Type are forms when they look
+// like a bare query string. Chunked bodies are not forms.
+func (r *Request) formBounds() (start, end int, ok bool) {
+	body := strings.TrimRight(r.Body(), "\r\n")
+	if body == "" {
+		return 0, 0, false
+	}
+	if te, found := r.Header("Transfer-Encoding"); found && hasChunked(te.Value) {
+		return 0, 0, false
+	}
+	if ct, found := r.Header("Content-Type"); found {
+		if !strings.Contains(strings.ToLower(ct.Value), "application/x-www-form-urlencoded") {
+			return 0, 0, false
+		}
+	} else if _, _, bare := queryBounds(body); !bare {
+		return 0, 0, false
+	}
+	return r.BodyStart, r.BodyStart + len(body), true
+}
+
+// shiftSpans moves spans, and the spans linked to them, by offset bytes
+func shiftSpans(spans []Span, offset int) []Span {
+	for i := range spans {
+		spans[i].Start += offset
+		spans[i].End += offset
+		spans[i].Also = shiftSpans(spans[i].Also, offset)
+	}
+	return spans
+}
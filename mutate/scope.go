@@ -0,0 +1,154 @@
+package mutate
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Scope restricts what a configuration fuzzes. Patterns are exact names,
+// globs where * and ? match any text and any character, or regular
+// expressions prefixed with "re:", all matched case-insensitively. Classes
+// are names from AllClasses, see Classify. An empty include list allows
+// everything.
+type Scope struct {
+	IncludeParams  []string `yaml:"include-params,omitempty"`  // Names of the insertion points to fuzz
+	ExcludeParams  []string `yaml:"exclude-params,omitempty"`  // Names of the insertion points never fuzzed
+	IncludeHosts   []string `yaml:"include-hosts,omitempty"`   // Hosts to fuzz, without port
+	ExcludeHosts   []string `yaml:"exclude-hosts,omitempty"`   // Hosts never fuzzed
+	IncludePaths   []string `yaml:"include-paths,omitempty"`   // Paths to fuzz, without query
+	ExcludePaths   []string `yaml:"exclude-paths,omitempty"`   // Paths never fuzzed
+	IncludeClasses []string `yaml:"include-classes,omitempty"` // Classes of the insertion points to fuzz
+	ExcludeClasses []string `yaml:"exclude-classes,omitempty"` // Classes of the insertion points never fuzzed
+}
+
+// ParsePatterns splits a comma-separated pattern list, "\," being a comma
+// inside a pattern
+func ParsePatterns(list string) []string {
+	var patterns []string
+	for _, p := range splitPayloads(list) {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// Validate checks that the patterns of s compile and its classes exist
+func (s Scope) Validate() error {
+	for _, class := range append(slices.Clone(s.IncludeClasses), s.ExcludeClasses...) {
+		if !slices.Contains(AllClasses, strings.ToLower(class)) {
+			return fmt.Errorf("invalid class: %s", class)
+		}
+	}
+	for _, list := range [][]string{s.IncludeParams, s.ExcludeParams, s.IncludeHosts, s.ExcludeHosts, s.IncludePaths, s.ExcludePaths} {
+		for _, p := range list {
+			if _, err := compilePattern(p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// withDefaults returns s with its empty lists taken from def
+func (s Scope) withDefaults(def Scope) Scope {
+	for _, f := range []struct{ list, def *[]string }{
+		{&s.IncludeParams, &def.IncludeParams},
+		{&s.ExcludeParams, &def.ExcludeParams},
+		{&s.IncludeHosts, &def.IncludeHosts},
+		{&s.ExcludeHosts, &def.ExcludeHosts},
+		{&s.IncludePaths, &def.IncludePaths},
+		{&s.ExcludePaths, &def.ExcludePaths},
+		{&s.IncludeClasses, &def.IncludeClasses},
+		{&s.ExcludeClasses, &def.ExcludeClasses},
+	} {
+		if len(*f.list) == 0 {
+			*f.list = *f.def
+		}
+	}
+	return s
+}
+
+// allows reports whether the host and path of a URL, or of the raw request
+// req when it is not nil, are in scope
+func (s Scope) allows(target string, req *Request) bool {
+	if len(s.IncludeHosts)+len(s.ExcludeHosts) > 0 && !inScope(s.IncludeHosts, s.ExcludeHosts, templateHost(target)) {
+		return false
+	}
+	if len(s.IncludePaths)+len(s.ExcludePaths) > 0 {
+		if req != nil {
+			target = req.Target
+		}
+		path := ""
+		if l, ok := parseURL(target); ok {
+			path = target[l.PathStart:l.PathEnd]
+		}
+		return inScope(s.IncludePaths, s.ExcludePaths, path)
+	}
+	return true
+}
+
+// filter returns the spans of target whose name and class are in scope.
+// Names only filter spans that have one, unlike path segments; classes
+// filter every span. It reports false when every span was filtered out, so
+// that nothing is fuzzed rather than the target echoed.
+func (s Scope) filter(target string, spans []Span) ([]Span, bool) {
+	byName := len(s.IncludeParams)+len(s.ExcludeParams) > 0
+	byClass := len(s.IncludeClasses)+len(s.ExcludeClasses) > 0
+	if !byName && !byClass || len(spans) == 0 {
+		return spans, true
+	}
+	var kept []Span
+	for _, span := range spans {
+		if byName && span.Name != "" && !inScope(s.IncludeParams, s.ExcludeParams, span.Name) {
+			continue
+		}
+		if byClass && !inScope(s.IncludeClasses, s.ExcludeClasses, Classify(span.Name, target[span.Start:span.End])) {
+			continue
+		}
+		kept = append(kept, span)
+	}
+	return kept, len(kept) > 0
+}
+
+// inScope reports whether name matches one of include, or include is
+// empty, and none of exclude
+func inScope(include, exclude []string, name string) bool {
+	return (len(include) == 0 || matchAny(include, name)) && !matchAny(exclude, name)
+}
+
+// matchAny reports whether name matches one of patterns
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if re, err := compilePattern(p); err == nil && re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+var patternCache sync.Map
+
+// compilePattern turns an exact name, glob or re: pattern into an anchored,
+// case-insensitive regular expression
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	expr, isRegexp := strings.CutPrefix(pattern, "re:")
+	if !isRegexp {
+		expr = regexp.QuoteMeta(pattern)
+		expr = strings.ReplaceAll(expr, `\*`, ".*")
+		expr = strings.ReplaceAll(expr, `\?`, ".")
+		expr = "^(?:" + expr + ")$"
+	}
+	re, err := regexp.Compile("(?i)" + expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %s", pattern)
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
@@ -0,0 +1,65 @@
+package mutate
+
+import (
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Placeholders expanded in payloads at every insertion point
+var templateRe = regexp.MustCompile(`\{\{(original|param|host|random|index)\}\}`)
+
+// expandTemplate replaces the placeholders of payload: {{original}} with the
+// original value of the insertion point, {{param}} with its name, {{host}}
+// with the host of the URL or request, {{random}} with a random string and
+// {{index}} with its position among the insertion points
+func expandTemplate(payload, target, original string, span Span) string {
+	if !strings.Contains(payload, "{{") {
+		return payload
+	}
+	return templateRe.ReplaceAllStringFunc(payload, func(placeholder string) string {
+		switch placeholder {
+		case "{{original}}":
+			return original
+		case "{{param}}":
+			return span.Name
+		case "{{host}}":
+			return templateHost(target)
+		case "{{random}}":
+			return randomString(8)
+		case "{{index}}":
+			return strconv.Itoa(span.Index)
+		}
+		return placeholder
+	})
+}
+
+// templateHost returns the hostname, without port, of a URL or of the Host
+// header of a raw request
+func templateHost(target string) string {
+	authority := ""
+	if l, ok := parseURL(target); ok && l.HostEnd > l.HostStart {
+		authority = target[l.HostStart:l.HostEnd]
+	} else if req, err := ParseRequest(target); err == nil {
+		if h, ok := req.Header("Host"); ok {
+			authority = h.Value
+		}
+	}
+	if host, _, err := net.SplitHostPort(authority); err == nil {
+		return host
+	}
+	return authority
+}
+
+// randomString returns n random lowercase letters and digits, which are
+// safe in any context including DNS labels
+func randomString(n int) string {
+	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = chars[rand.IntN(len(chars))]
+	}
+	return string(b)
+}
@@ -0,0 +1,184 @@
+package mutate
+
+import "strings"
+
+// xmlSpans locates the text nodes, CDATA sections and attribute values of
+// an XML body in document order. Namespace declarations are left alone and
+// payloads are inserted without escaping, so that entity references and
+// markup can be injected. With withDoctype, the DOCTYPE declaration is the
+// first insertion point: the existing declaration, or an empty point after
+// the XML declaration where a DOCTYPE with entities can be inserted for XXE
+// testing.
+func xmlSpans(req *Request, withDoctype bool) []Span {
+	start, end, ok := req.xmlBounds()
+	if !ok {
+		return nil
+	}
+	s := req.Raw[:end]
+
+	var spans []Span
+	doctype := Span{Start: start, End: start}
+	element := ""
+	for pos := start; pos < end; {
+		lt := strings.IndexByte(s[pos:], '<')
+		if lt < 0 {
+			lt = end - pos
+		}
+		spans = appendXMLText(spans, s, pos, pos+lt, element)
+		pos += lt
+		if pos >= end {
+			break
+		}
+
+		rest := s[pos:]
+		switch {
+		case strings.HasPrefix(rest, "<?"):
+			close := strings.Index(rest, "?>")
+			if close < 0 {
+				return spans
+			}
+			if strings.HasPrefix(rest, "<?xml") && strings.TrimSpace(s[start:pos]) == "" {
+				doctype = Span{Start: pos + close + 2, End: pos + close + 2}
+			}
+			pos += close + 2
+		case strings.HasPrefix(rest, "<!--"):
+			close := strings.Index(rest, "-->")
+			if close < 0 {
+				return spans
+			}
+			pos += close + 3
+		case strings.HasPrefix(rest, "<![CDATA["):
+			close := strings.Index(rest, "]]>")
+			if close < 0 {
+				return spans
+			}
+			spans = append(spans, Span{Start: pos + len("<![CDATA["), End: pos + close, Name: element})
+			pos += close + 3
+		case strings.HasPrefix(rest, "<!"):
+			close := doctypeEnd(rest)
+			if close < 0 {
+				return spans
+			}
+			doctype = Span{Start: pos, End: pos + close}
+			pos += close
+		default:
+			close := tagEnd(rest)
+			if close < 0 {
+				return spans
+			}
+			if !strings.HasPrefix(rest, "</") {
+				if fields := strings.Fields(strings.TrimSuffix(rest[1:close], "/")); len(fields) > 0 {
+					element = fields[0]
+				}
+				spans = append(spans, xmlAttributeSpans(s, pos+1, pos+close)...)
+			}
+			pos += close + 1
+		}
+	}
+
+	if withDoctype {
+		spans = append([]Span{doctype}, spans...)
+	}
+	return spans
+}
+
+// appendXMLText adds the text s[start:end] of element without surrounding
+// whitespace, unless it is blank
+func appendXMLText(spans []Span, s string, start, end int, element string) []Span {
+	text := s[start:end]
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return spans
+	}
+	start += strings.Index(text, trimmed)
+	return append(spans, Span{Start: start, End: start + len(trimmed), Name: element})
+}
+
+// xmlAttributeSpans locates the quoted attribute values of the tag s[start:end]
+func xmlAttributeSpans(s string, start, end int) []Span {
+	var spans []Span
+	for pos := start; pos < end; {
+		eq := strings.IndexByte(s[pos:end], '=')
+		if eq < 0 {
+			break
+		}
+		name := strings.Fields(s[pos : pos+eq])
+		pos += eq + 1
+		for pos < end && strings.IndexByte(" \t\r\n", s[pos]) >= 0 {
+			pos++
+		}
+		if pos >= end || (s[pos] != '"' && s[pos] != '\'') {
+			continue
+		}
+		close := strings.IndexByte(s[pos+1:end], s[pos])
+		if close < 0 {
+			break
+		}
+		if len(name) > 0 {
+			if n := name[len(name)-1]; n != "xmlns" && !strings.HasPrefix(n, "xmlns:") {
+				spans = append(spans, Span{Start: pos + 1, End: pos + 1 + close, Name: n})
+			}
+		}
+		pos += close + 2
+	}
+	return spans
+}
+
+// tagEnd returns the offset of the '>' closing the tag starting s, skipping
+// quoted attribute values, or -1 when it is not closed
+func tagEnd(s string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+// doctypeEnd returns the length of the declaration starting s, internal
+// subset included, or -1 when it is not closed
+func doctypeEnd(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '>':
+			if depth <= 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// xmlBounds returns the offsets of an XML body: any body with an XML
+// Content-Type, such as SOAP envelopes, or without Content-Type when it
+// starts with a tag
+func (r *Request) xmlBounds() (start, end int, ok bool) {
+	body := strings.TrimRight(r.Body(), " \t\r\n")
+	if te, found := r.Header("Transfer-Encoding"); found && hasChunked(te.Value) {
+		return 0, 0, false
+	}
+	if ct, found := r.Header("Content-Type"); found {
+		if !strings.Contains(strings.ToLower(ct.Value), "xml") {
+			return 0, 0, false
+		}
+	} else if !strings.HasPrefix(strings.TrimSpace(body), "<") {
+		return 0, 0, false
+	}
+	if body == "" {
+		return 0, 0, false
+	}
+	return r.BodyStart, r.BodyStart + len(body), true
+}
@@ -0,0 +1,80 @@
+package mutate
+
+import (
+	"slices"
+	"testing"
+)
+
+const xmlRequest = "POST /soap HTTP/1.1\r\nHost: x\r\nContent-Type: text/xml\r\n\r\n"
+
+func TestXMLSpans(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		doctype bool
+		want    []string
+		names   []string
+	}{
+		{"text", `<a><b>x</b> <c> y </c></a>`, false, []string{"x", "y"}, []string{"b", "c"}},
+		{"attributes", `<a id="1" n='v' xmlns="urn:x" xmlns:s="urn:s">t</a>`, false, []string{"1", "v", "t"}, []string{"id", "n", "a"}},
+		{"quoted >", `<a b="x>y" c='<'>t</a>`, false, []string{"x>y", "<", "t"}, []string{"b", "c", "a"}},
+		{"self-closing", `<a><b k="v"/>t</a>`, false, []string{"v", "t"}, []string{"k", "b"}},
+		{"cdata", `<a><![CDATA[<x>]]></a>`, false, []string{"<x>"}, []string{"a"}},
+		{"comment", `<a><!-- <b>c</b> -->t</a>`, false, []string{"t"}, []string{"a"}},
+		{"declaration", `<?xml version="1.0"?><a>t</a>`, false, []string{"t"}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := ParseRequest(xmlRequest + tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var values, names []string
+			for _, span := range xmlSpans(req, tt.doctype) {
+				values = append(values, req.Raw[span.Start:span.End])
+				names = append(names, span.Name)
+			}
+			if !slices.Equal(values, tt.want) {
+				t.Errorf("values: got %q, want %q", values, tt.want)
+			}
+			if !slices.Equal(names, tt.names) {
+				t.Errorf("names: got %q, want %q", names, tt.names)
+			}
+		})
+	}
+}
+
+func TestXMLDoctype(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"no declaration", `<a>t</a>`, `FUZZ<a>t</a>`},
+		{"declaration", `<?xml version="1.0"?><a>t</a>`, `<?xml version="1.0"?>FUZZ<a>t</a>`},
+		{"existing doctype", `<?xml version="1.0"?><!DOCTYPE a [<!ENTITY e "v">]><a>t</a>`, `<?xml version="1.0"?>FUZZ<a>t</a>`},
+		{"doctype without declaration", `<!DOCTYPE a><a>t</a>`, `FUZZ<a>t</a>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := ParseRequest(xmlRequest + tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			spans := xmlSpans(req, true)
+			if len(spans) == 0 {
+				t.Fatal("no insertion points")
+			}
+			got, err := ApplySpans(req.Raw, spans[:1], "FUZZ", "replace", "single")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || got[0][len(xmlRequest):] != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if n := len(xmlSpans(req, false)); n != len(spans)-1 {
+				t.Errorf("got %d insertion points without doctype, want %d", n, len(spans)-1)
+			}
+		})
+	}
+}
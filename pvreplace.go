@@ -2,77 +2,301 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/rix4uni/pvreplace/banner"
-	"gopkg.in/yaml.v3"
+	"github.com/rix4uni/pvreplace/mutate"
 )
 
-// Config represents the root structure of the YAML config file
-type Config struct {
-	Configurations []FuzzingConfig `yaml:"configurations"`
+var (
+	verbose    *bool
+	countOnly  *bool
+	format     *string
+	countTotal int
+)
+
+// payloadSets collects the repeatable -payload-set flag
+type payloadSets []string
+
+func (p *payloadSets) String() string     { return strings.Join(*p, " ") }
+func (p *payloadSets) Set(v string) error { *p = append(*p, v); return nil }
+
+// Function to get the default ignore-lines.txt path
+func getDefaultIgnoreLinesPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %v", err)
+	}
+	configDir := filepath.Join(homeDir, ".config", "pvreplace")
+	return filepath.Join(configDir, "ignore-lines.txt"), nil
+}
+
+// Function to get the default config.yaml path
+func getDefaultConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %v", err)
+	}
+	configDir := filepath.Join(homeDir, ".config", "pvreplace")
+	return filepath.Join(configDir, "config.yaml"), nil
+}
+
+// Function to download a file from the pvreplace GitHub repository
+func downloadFile(name, filePath string) error {
+	url := "https://raw.githubusercontent.com/rix4uni/pvreplace/refs/heads/main/" + name
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return fmt.Errorf("error downloading %s: %v", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error downloading %s: HTTP %d", name, resp.StatusCode)
+	}
+
+	// Create directory if it doesn't exist
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating config directory: %v", err)
+	}
+
+	// Create the file
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %v", name, err)
+	}
+	defer file.Close()
+
+	// Write the downloaded content to file
+	_, err = io.Copy(file, resp.Body)
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", name, err)
+	}
+
+	if *verbose {
+		fmt.Fprintf(os.Stderr, "[+] Downloaded %s to: %s\n", name, filePath)
+	}
+	return nil
+}
+
+// Function to ensure a default file exists, downloading it from GitHub if missing
+func ensureDefaultFile(name string, getPath func() (string, error)) (string, error) {
+	defaultPath, err := getPath()
+	if err != nil {
+		return "", err
+	}
+
+	// Check if file exists
+	if _, err := os.Stat(defaultPath); os.IsNotExist(err) {
+		// Download the file from GitHub
+		if err := downloadFile(name, defaultPath); err != nil {
+			return "", err
+		}
+	}
+
+	return defaultPath, nil
+}
+
+// Function to get the default output directory path
+func getDefaultOutputPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error getting home directory: %v", err)
+	}
+	return filepath.Join(homeDir, ".config", "pvreplace", "modified_request"), nil
+}
+
+// Function to ensure output directory exists
+func ensureOutputDir(outputPath string) error {
+	if err := os.MkdirAll(outputPath, 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+	return nil
+}
+
+// Function to load the configurations from -config or the default config.yaml
+func loadConfigs(configPath string, options *mutate.PartOptions) ([]mutate.FuzzingConfig, error) {
+	if configPath == "" {
+		// Use default config path
+		defaultPath, err := ensureDefaultFile("config.yaml", getDefaultConfigPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not use default config.yaml: %v\n", err)
+			return nil, nil
+		}
+		configPath = defaultPath
+	}
+	return readConfig(configPath, options)
+}
+
+// Function to read a config file and merge its part settings into options,
+// the -marker, -json-values and -xml-doctype flags taking precedence
+func readConfig(configPath string, options *mutate.PartOptions) ([]mutate.FuzzingConfig, error) {
+	config, err := mutate.ReadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	fileOptions := config.PartOptions()
+	if config.Markers == "" || isFlagSet("marker") {
+		fileOptions.MarkerOpen, fileOptions.MarkerClose = options.MarkerOpen, options.MarkerClose
+	}
+	if config.JSONValues == "" || isFlagSet("json-values") {
+		fileOptions.JSONValues = options.JSONValues
+	}
+	if !config.XMLDoctype || isFlagSet("xml-doctype") {
+		fileOptions.XMLDoctype = options.XMLDoctype
+	}
+	*options = fileOptions
+	return config.Active(), nil
+}
+
+// Function to report configurations the mutator could not apply
+func reportErrors(err error) {
+	if err == nil {
+		return
+	}
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	} else {
+		errs = []error{err}
+	}
+	for _, e := range errs {
+		// Unsupported modes are expected with "all" and only shown in verbose
+		// mode, while "all" skips the parts with the wrong target, so those are
+		// always named explicitly and shown
+		if errors.Is(e, mutate.ErrUnsupportedMode) && !*verbose {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", e)
+	}
+}
+
+// Function to print the total number of variants with -count
+func printCountTotal() {
+	if *countOnly {
+		fmt.Printf("%d total\n", countTotal)
+	}
+}
+
+// Function to check whether a flag was set on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// Function to check whether configurations fuzz header parts
+func usesHeaderParts(configs []mutate.FuzzingConfig) bool {
+	return slices.ContainsFunc(configs, func(c mutate.FuzzingConfig) bool {
+		return slices.Contains(mutate.HeaderParts, c.FuzzingPart)
+	})
+}
+
+// Function to check whether payloads are generated, by -payload, -payload-set
+// or the configurations
+func usesGenerators(payload string, sets []string, configs []mutate.FuzzingConfig) bool {
+	return mutate.UsesGenerators(payload) || slices.ContainsFunc(sets, mutate.UsesGenerators) ||
+		slices.ContainsFunc(configs, func(c mutate.FuzzingConfig) bool { return c.Payloads.UsesGenerators() })
+}
+
+// Function to print every variant of a URL, or their number with -count
+func printURL(m *mutate.Mutator, url string) {
+	if *format != mutate.FormatURL {
+		printURLRequest(m, url)
+		return
+	}
+
+	if *countOnly {
+		n, err := m.CountURL(url)
+		fmt.Printf("%d %s\n", n, url)
+		countTotal = min(countTotal, math.MaxInt-n) + n
+		reportErrors(err)
+		return
+	}
+
+	err := m.EachURL(url, func(v mutate.Variant) {
+		fmt.Println(v.Value)
+	})
+	reportErrors(err)
 }
 
-// FuzzingConfig represents a single fuzzing configuration
-type FuzzingConfig struct {
-	FuzzingPart string `yaml:"fuzzing-part"`
-	FuzzingType string `yaml:"fuzzing-type"`
-	FuzzingMode string `yaml:"fuzzing-mode"`
-	Ignore      bool   `yaml:"ignore,omitempty"`
+// Function to print the variants of the request built from a URL in the -format output format
+func printURLRequest(m *mutate.Mutator, url string) {
+	if *countOnly {
+		n, err := m.CountURLRequest(url)
+		fmt.Printf("%d %s\n", n, url)
+		countTotal = min(countTotal, math.MaxInt-n) + n
+		reportErrors(err)
+		return
+	}
+
+	err := m.EachURLRequest(url, func(v mutate.Variant) {
+		out, err := mutate.FormatRequest(v.Value, url, *format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting request: %v\n", err)
+			return
+		}
+		if *format == mutate.FormatRaw {
+			// Requests end with the blank line separating them
+			fmt.Print(out)
+			return
+		}
+		fmt.Println(out)
+	})
+	reportErrors(err)
 }
 
 func main() {
 	// Define command-line flags
-	payload := flag.String("payload", "FUZZ", "Comma-separated list of payloads or a file with payloads")
+	payload := flag.String("payload", "FUZZ", "Comma-separated list of payloads (\\, for a comma, \\@ or \\file: for a literal @ or file:), sources (file:path, @path, dir:path, - for stdin) and generators (range:1-100[:step], chars:a-z[:len=1-3], date:2024-01-01..2024-12-31, uuid:10), or a .txt file with payloads")
 	url := flag.String("u", "", "The URL to process")
 	list := flag.String("list", "", "File containing URLs to process")
 	raw := flag.String("raw", "", "File containing Burp Suite raw request data to process")
 	ignoreLines := flag.String("ignore-lines", "", "Comma-separated list or file of lines to ignore in raw data")
 	fuzzingMode := flag.String("fuzzing-mode", "multiple", "Fuzzing mode: single, multiple")
 	fuzzingType := flag.String("fuzzing-type", "replace", "Fuzzing type: replace, prefix, postfix")
-	fuzzingPart := flag.String("fuzzing-part", "param-value", "Fuzzing part: param-value, param-name, path-suffix, path-suffix-slash, path-segment, path-ext, headers, all")
+	fuzzingPart := flag.String("fuzzing-part", "param-value", "Fuzzing part: "+strings.Join(mutate.PartNames(), ", ")+", all")
+	attack := flag.String("attack", "", "Attack type replacing -fuzzing-mode: sniper, battering-ram, pitchfork, cluster-bomb")
+	var sets payloadSets
+	flag.Var(&sets, "payload-set", "Payload set for the next insertion point of an attack, like -payload (repeatable)")
+	maxVariants := flag.Int("max-variants", 100000, "Refuse attacks generating more variants than this per input (0 for no limit)")
+	marker := flag.String("marker", mutate.DefaultMarker, "Insertion marker delimiter for -fuzzing-part markers, or opening and closing delimiters separated by a space")
+	jsonValues := flag.String("json-values", mutate.JSONValuesKeep, "How json-value and json-key insert payloads: keep (strings stay quoted, other leaves get the payload as-is), string (every leaf becomes a string), raw (no escaping)")
+	xmlDoctype := flag.Bool("xml-doctype", false, "Add the DOCTYPE declaration as the first insertion point of -fuzzing-part xml, for XXE testing")
+	encode := flag.String("encode", "", "Comma-separated encoder chain applied to payloads: "+strings.Join(mutate.AllEncoders, ", ")+", or none to also skip the context encoding")
+	format = flag.String("format", mutate.FormatURL, "Output format of URL variants: url, raw (HTTP request), curl, jsonl; header parts default to raw")
+	includeParams := flag.String("include-params", "", "Comma-separated names of the parameters, headers and fields to fuzz: exact, glob (utm_*) or regex (re:^id$)")
+	excludeParams := flag.String("exclude-params", "", "Comma-separated names of the parameters, headers and fields never fuzzed, like -include-params")
+	includeHosts := flag.String("include-hosts", "", "Comma-separated hosts to fuzz, like -include-params (*.example.com)")
+	excludeHosts := flag.String("exclude-hosts", "", "Comma-separated hosts never fuzzed, like -include-params")
+	includePaths := flag.String("include-paths", "", "Comma-separated paths to fuzz, like -include-params (/api/*)")
+	excludePaths := flag.String("exclude-paths", "", "Comma-separated paths never fuzzed, like -include-params")
+	includeClasses := flag.String("include-classes", "", "Comma-separated classes of the parameters to fuzz: "+strings.Join(mutate.AllClasses, ", "))
+	excludeClasses := flag.String("exclude-classes", "", "Comma-separated classes of the parameters never fuzzed, like -include-classes")
+	countOnly = flag.Bool("count", false, "Print the number of variants per input instead of generating them")
+	keepLength := flag.Bool("keep-length", false, "Keep Content-Length and Transfer-Encoding of raw requests as-is instead of recomputing them")
+	dedupe := flag.String("dedupe", mutate.DedupeExact, "Deduplication: exact (drop duplicate outputs), shape (also skip URLs with an already seen host, path and parameter names), none")
 	config := flag.String("config", "", "Path to YAML config file with fuzzing configurations")
 	output := flag.String("output", "", "Directory to save modified requests (default: ~/.config/pvreplace/modified_request)")
 	silent := flag.Bool("silent", false, "Silent mode.")
 	version := flag.Bool("version", false, "Print the version of the tool and exit.")
-	verbose := flag.Bool("verbose", false, "Show detailed information about what's being processed.")
+	verbose = flag.Bool("verbose", false, "Show detailed information about what's being processed.")
 	flag.Parse()
 
-	// Define all available fuzzing parts
-	allFuzzingParts := []string{"param-value", "param-name", "path-suffix", "path-suffix-slash", "path-segment", "path-ext", "headers"}
-
-	// Function to load and parse config file
-	loadConfig := func(configPath string) ([]FuzzingConfig, error) {
-		file, err := os.Open(configPath)
-		if err != nil {
-			return nil, fmt.Errorf("error opening config file: %v", err)
-		}
-		defer file.Close()
-
-		var config Config
-		decoder := yaml.NewDecoder(file)
-		if err := decoder.Decode(&config); err != nil {
-			return nil, fmt.Errorf("error parsing config file: %v", err)
-		}
-
-		// Filter out ignored configurations
-		var activeConfigs []FuzzingConfig
-		for _, cfg := range config.Configurations {
-			if !cfg.Ignore {
-				activeConfigs = append(activeConfigs, cfg)
-			}
-		}
-
-		return activeConfigs, nil
-	}
-
 	// Print version and exit if -version flag is provided
 	if *version {
 		banner.PrintBanner()
@@ -97,11 +321,21 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Validate that -config cannot be used with -fuzzing-mode, -fuzzing-type, or -fuzzing-part
+	// Validate that payloads are only read from stdin when it does not provide the URLs
+	if *url == "" && *list == "" && *raw == "" {
+		for _, input := range append([]string{*payload}, sets...) {
+			if mutate.UsesStdin(input) {
+				fmt.Fprintf(os.Stderr, "Error: payloads can only be read from stdin with -u, -list or -raw\n")
+				os.Exit(1)
+			}
+		}
+	}
+
+	// Validate that -config cannot be used with -fuzzing-mode, -fuzzing-type, -fuzzing-part or -attack
 	if *config != "" {
 		var conflictingFlags []string
 		flag.Visit(func(f *flag.Flag) {
-			if f.Name == "fuzzing-mode" || f.Name == "fuzzing-type" || f.Name == "fuzzing-part" {
+			if f.Name == "fuzzing-mode" || f.Name == "fuzzing-type" || f.Name == "fuzzing-part" || f.Name == "attack" {
 				conflictingFlags = append(conflictingFlags, "-"+f.Name)
 			}
 		})
@@ -111,480 +345,148 @@ func main() {
 		}
 	}
 
-	// Regular expressions for different fuzzing parts
-	reValue := regexp.MustCompile(`=[^&\s]*`)                                                                 // For parameter values
-	reName := regexp.MustCompile(`([?&])([^&=]+)=`)                                                           // For parameter names
-	rePathSuffix := regexp.MustCompile(`/([^/]+\.(php|asp|aspx|jsp|jspx|xml))`)                               // For URL paths
-	rePathSegment := regexp.MustCompile(`(https?://(?:[^/]+/)+)([^/]+)/([^/]+\.(php|aspx|asp|jsp|jspx|xml))`) // For path segment
-	rePathExt := regexp.MustCompile(`/([^/]+)\.(php|aspx|asp|jsp|jspx|xml)`)                                  // For file extensions in paths
-	reUserAgent := regexp.MustCompile(`^(User-Agent:\s)(.*)$`)                                                // For matching headers
-	reHeader := regexp.MustCompile(`^(User-Agent|Referer|Cookie|X-Forwarded-For|X-Real-IP):\s*(.*)$`)         // For matching injectable headers
-
-	// Function to read payloads from a file or comma-separated list
-	getPayloads := func(input string) ([]string, error) {
-		if strings.HasSuffix(input, ".txt") {
-			file, err := os.Open(input)
-			if err != nil {
-				return nil, fmt.Errorf("error opening payload file: %v", err)
-			}
-			defer file.Close()
-
-			var payloads []string
-			scanner := bufio.NewScanner(file)
-			for scanner.Scan() {
-				payload := strings.TrimSpace(scanner.Text())
-				if payload != "" {
-					payloads = append(payloads, payload)
-				}
-			}
-
-			if err := scanner.Err(); err != nil {
-				return nil, fmt.Errorf("error reading payload file: %v", err)
-			}
-			return payloads, nil
-		}
-
-		// Split comma-separated values
-		return strings.Split(input, ","), nil
+	markerOpen, markerClose := mutate.ParseMarkers(*marker)
+	if markerOpen == "" {
+		fmt.Fprintf(os.Stderr, "Error: -marker: invalid empty marker\n")
+		os.Exit(1)
 	}
-
-	// Function to read ignore lines from a file or a comma-separated list
-	getIgnoreLines := func(input string) ([]string, error) {
-		if strings.HasSuffix(input, ".txt") {
-			file, err := os.Open(input)
-			if err != nil {
-				return nil, fmt.Errorf("error opening ignore lines file: %v", err)
-			}
-			defer file.Close()
-
-			var lines []string
-			scanner := bufio.NewScanner(file)
-			for scanner.Scan() {
-				line := strings.TrimSpace(scanner.Text())
-				if line != "" {
-					lines = append(lines, line)
-				}
-			}
-
-			if err := scanner.Err(); err != nil {
-				return nil, fmt.Errorf("error reading ignore lines file: %v", err)
-			}
-			return lines, nil
-		}
-
-		// Split comma-separated values
-		return strings.Split(input, ","), nil
+	options := mutate.PartOptions{MarkerOpen: markerOpen, MarkerClose: markerClose, JSONValues: *jsonValues, XMLDoctype: *xmlDoctype}
+	if err := options.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -json-values: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Function to get the default ignore-lines.txt path
-	getDefaultIgnoreLinesPath := func() (string, error) {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return "", fmt.Errorf("error getting home directory: %v", err)
-		}
-		configDir := filepath.Join(homeDir, ".config", "pvreplace")
-		return filepath.Join(configDir, "ignore-lines.txt"), nil
+	if _, err := mutate.ParseEncoders(*encode); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -encode: %v\n", err)
+		os.Exit(1)
 	}
-
-	// Function to get the default config.yaml path
-	getDefaultConfigPath := func() (string, error) {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return "", fmt.Errorf("error getting home directory: %v", err)
-		}
-		configDir := filepath.Join(homeDir, ".config", "pvreplace")
-		return filepath.Join(configDir, "config.yaml"), nil
+	scope := mutate.Scope{
+		IncludeParams:  mutate.ParsePatterns(*includeParams),
+		ExcludeParams:  mutate.ParsePatterns(*excludeParams),
+		IncludeHosts:   mutate.ParsePatterns(*includeHosts),
+		ExcludeHosts:   mutate.ParsePatterns(*excludeHosts),
+		IncludePaths:   mutate.ParsePatterns(*includePaths),
+		ExcludePaths:   mutate.ParsePatterns(*excludePaths),
+		IncludeClasses: mutate.ParsePatterns(*includeClasses),
+		ExcludeClasses: mutate.ParsePatterns(*excludeClasses),
 	}
-
-	// Function to download ignore-lines.txt from GitHub
-	downloadIgnoreLines := func(filePath string) error {
-		url := "https://raw.githubusercontent.com/rix4uni/pvreplace/refs/heads/main/ignore-lines.txt"
-
-		resp, err := http.Get(url)
-		if err != nil {
-			return fmt.Errorf("error downloading ignore-lines.txt: %v", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("error downloading ignore-lines.txt: HTTP %d", resp.StatusCode)
-		}
-
-		// Create directory if it doesn't exist
-		dir := filepath.Dir(filePath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("error creating config directory: %v", err)
-		}
-
-		// Create the file
-		file, err := os.Create(filePath)
-		if err != nil {
-			return fmt.Errorf("error creating ignore-lines.txt: %v", err)
-		}
-		defer file.Close()
-
-		// Write the downloaded content to file
-		_, err = io.Copy(file, resp.Body)
-		if err != nil {
-			return fmt.Errorf("error writing ignore-lines.txt: %v", err)
-		}
-
-		if *verbose {
-			fmt.Fprintf(os.Stderr, "[+] Downloaded ignore-lines.txt to: %s\n", filePath)
-		}
-		return nil
+	if err := scope.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-
-	// Function to download config.yaml from GitHub
-	downloadConfig := func(filePath string) error {
-		url := "https://raw.githubusercontent.com/rix4uni/pvreplace/refs/heads/main/config.yaml"
-
-		resp, err := http.Get(url)
-		if err != nil {
-			return fmt.Errorf("error downloading config.yaml: %v", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("error downloading config.yaml: HTTP %d", resp.StatusCode)
-		}
-
-		// Create directory if it doesn't exist
-		dir := filepath.Dir(filePath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("error creating config directory: %v", err)
-		}
-
-		// Create the file
-		file, err := os.Create(filePath)
-		if err != nil {
-			return fmt.Errorf("error creating config.yaml: %v", err)
-		}
-		defer file.Close()
-
-		// Write the downloaded content to file
-		_, err = io.Copy(file, resp.Body)
-		if err != nil {
-			return fmt.Errorf("error writing config.yaml: %v", err)
-		}
-
-		if *verbose {
-			fmt.Fprintf(os.Stderr, "[+] Downloaded config.yaml to: %s\n", filePath)
-		}
-		return nil
+	if !slices.Contains(mutate.AllFormats, *format) {
+		fmt.Fprintf(os.Stderr, "Error: invalid -format: %s\n", *format)
+		os.Exit(1)
 	}
 
-	// Function to ensure default ignore-lines.txt exists
-	ensureDefaultIgnoreLines := func() (string, error) {
-		defaultPath, err := getDefaultIgnoreLinesPath()
-		if err != nil {
-			return "", err
-		}
-
-		// Check if file exists
-		if _, err := os.Stat(defaultPath); os.IsNotExist(err) {
-			// Download the file from GitHub
-			if err := downloadIgnoreLines(defaultPath); err != nil {
-				return "", err
-			}
-		}
-
-		return defaultPath, nil
+	deduper, err := mutate.NewDeduper(*dedupe)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Function to ensure default config.yaml exists
-	ensureDefaultConfig := func() (string, error) {
-		defaultPath, err := getDefaultConfigPath()
-		if err != nil {
-			return "", err
-		}
-
-		// Check if file exists
-		if _, err := os.Stat(defaultPath); os.IsNotExist(err) {
-			// Download the file from GitHub
-			if err := downloadConfig(defaultPath); err != nil {
-				return "", err
-			}
-		}
-
-		return defaultPath, nil
+	payloads, err := mutate.ParsePayloads(*payload)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return
 	}
 
-	// Function to get the default output directory path
-	getDefaultOutputPath := func() (string, error) {
-		homeDir, err := os.UserHomeDir()
+	var payloadSetList [][]string
+	for _, set := range sets {
+		setPayloads, err := mutate.LoadPayloads(set)
 		if err != nil {
-			return "", fmt.Errorf("error getting home directory: %v", err)
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return
 		}
-		return filepath.Join(homeDir, ".config", "pvreplace", "modified_request"), nil
+		payloadSetList = append(payloadSetList, setPayloads)
+	}
+	newMutator := func(configs []mutate.FuzzingConfig) *mutate.Mutator {
+		// One hash per output of a generator would take more memory than the
+		// wordlist it replaces, so only inputs are deduplicated
+		if usesGenerators(*payload, sets, configs) {
+			deduper.SkipOutputs()
+		}
+		m := mutate.New(nil, configs)
+		m.Source = payloads
+		m.PayloadSets = payloadSetList
+		m.MaxVariants = *maxVariants
+		m.Dedupe = deduper
+		m.KeepLength = *keepLength
+		m.Encode = *encode
+		m.Scope = scope
+		m.Options = options
+		return m
 	}
 
-	// Function to ensure output directory exists
-	ensureOutputDir := func(outputPath string) error {
-		if err := os.MkdirAll(outputPath, 0755); err != nil {
-			return fmt.Errorf("error creating output directory: %v", err)
+	// Use flag-based configuration when no configuration is loaded
+	flagConfig := func() []mutate.FuzzingConfig {
+		cfg := mutate.FuzzingConfig{
+			FuzzingPart: *fuzzingPart,
+			FuzzingType: *fuzzingType,
+			FuzzingMode: *fuzzingMode,
+			Attack:      *attack,
 		}
-		return nil
-	}
-
-	// Function to process and replace parts of a URL based on fuzzing mode, type, and part
-	processURL := func(url, payload, mode, ftype, part string) {
-		var modifiedURL string
-
-		switch part {
-		case "param-value":
-			if mode == "multiple" {
-				switch ftype {
-				case "replace":
-					modifiedURL = reValue.ReplaceAllString(url, "="+payload)
-				case "prefix":
-					modifiedURL = reValue.ReplaceAllString(url, "="+payload+"${0}")
-				case "postfix":
-					modifiedURL = reValue.ReplaceAllString(url, "${0}"+payload)
-				default:
-					fmt.Fprintf(os.Stderr, "Invalid fuzzing type: %s\n", ftype)
-					return
-				}
-				fmt.Println(modifiedURL)
-			} else if mode == "single" {
-				for _, match := range reValue.FindAllStringIndex(url, -1) {
-					switch ftype {
-					case "replace":
-						modifiedURL = url[:match[0]] + "=" + payload + url[match[1]:]
-					case "prefix":
-						modifiedURL = url[:match[0]] + "=" + payload + url[match[0]+1:]
-					case "postfix":
-						modifiedURL = url[:match[0]] + url[match[0]:match[1]] + payload + url[match[1]:]
-					default:
-						fmt.Fprintf(os.Stderr, "Invalid fuzzing type: %s\n", ftype)
-						return
-					}
-					fmt.Println(modifiedURL)
-				}
-			}
-
-		case "param-name":
-			if mode == "multiple" {
-				switch ftype {
-				case "replace":
-					modifiedURL = reName.ReplaceAllString(url, "${1}"+payload+"=")
-				case "prefix":
-					modifiedURL = reName.ReplaceAllString(url, "${1}"+payload+"${2}=")
-				case "postfix":
-					modifiedURL = reName.ReplaceAllString(url, "${1}${2}"+payload+"=")
-				default:
-					fmt.Fprintf(os.Stderr, "Invalid fuzzing type: %s\n", ftype)
-					return
-				}
-				fmt.Println(modifiedURL)
-			} else if mode == "single" {
-				for _, match := range reName.FindAllStringSubmatchIndex(url, -1) {
-					switch ftype {
-					case "replace":
-						modifiedURL = url[:match[2]] + url[match[2]:match[3]] + payload + url[match[5]:]
-					case "prefix":
-						modifiedURL = url[:match[2]] + url[match[2]:match[4]] + payload + url[match[4]:]
-					case "postfix":
-						modifiedURL = url[:match[2]] + url[match[2]:match[5]] + payload + url[match[5]:]
-					default:
-						fmt.Fprintf(os.Stderr, "Invalid fuzzing type: %s\n", ftype)
-						return
-					}
-					fmt.Println(modifiedURL)
-				}
-			}
-
-		case "path-suffix":
-			if mode == "multiple" {
-				switch ftype {
-				case "replace":
-					modifiedURL = rePathSuffix.ReplaceAllString(url, "/"+payload)
-				case "prefix":
-					modifiedURL = rePathSuffix.ReplaceAllString(url, "/"+payload+"${1}")
-				case "postfix":
-					modifiedURL = rePathSuffix.ReplaceAllString(url, "${0}"+payload)
-				default:
-					fmt.Fprintf(os.Stderr, "Invalid fuzzing type: %s\n", ftype)
-					return
-				}
-				fmt.Println(modifiedURL)
-			} else if mode == "single" {
-				for _, match := range rePathSuffix.FindAllStringIndex(url, -1) {
-					switch ftype {
-					case "replace":
-						modifiedURL = url[:match[0]] + "/" + payload + url[match[1]:]
-					case "prefix":
-						modifiedURL = url[:match[0]+1] + payload + url[match[0]+1:]
-					case "postfix":
-						modifiedURL = url[:match[0]] + url[match[0]:match[1]] + payload + url[match[1]:]
-					default:
-						fmt.Fprintf(os.Stderr, "Invalid fuzzing type: %s\n", ftype)
-						return
-					}
-					fmt.Println(modifiedURL)
-				}
-			}
-
-		case "path-suffix-slash":
-			if mode == "multiple" {
-				switch ftype {
-				case "replace":
-					modifiedURL = rePathSuffix.ReplaceAllString(url, "${0}/"+payload)
-				default:
-					fmt.Fprintf(os.Stderr, "Invalid fuzzing type: %s (path-suffix-slash only supports replace)\n", ftype)
-					return
-				}
-				fmt.Println(modifiedURL)
-			} else if mode == "single" {
-				for _, match := range rePathSuffix.FindAllStringIndex(url, -1) {
-					switch ftype {
-					case "replace":
-						modifiedURL = url[:match[1]] + "/" + payload + url[match[1]:]
-					default:
-						fmt.Fprintf(os.Stderr, "Invalid fuzzing type: %s (path-suffix-slash only supports replace)\n", ftype)
-						return
-					}
-					fmt.Println(modifiedURL)
-				}
-			}
+		if err := cfg.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return []mutate.FuzzingConfig{cfg}
+	}
 
-		case "path-segment":
-			if mode == "multiple" {
-				switch ftype {
-				case "replace":
-					modifiedURL = rePathSegment.ReplaceAllString(url, "${1}"+payload+"/${3}")
-				case "prefix":
-					modifiedURL = rePathSegment.ReplaceAllString(url, "${1}"+payload+"${2}/${3}")
-				case "postfix":
-					modifiedURL = rePathSegment.ReplaceAllString(url, "${1}${2}"+payload+"/${3}")
-				default:
-					fmt.Fprintf(os.Stderr, "Invalid fuzzing type: %s\n", ftype)
-					return
-				}
-				fmt.Println(modifiedURL)
-			} else if mode == "single" {
-				if *verbose {
-					fmt.Println("You cannot use -fuzzing-mode single with -fuzzing-part path-segment")
-				}
+	// Handle Burp Suite raw request data passed via the `-raw` flag
+	if *raw != "" {
+		// Raw requests only use fuzzing parts when asked to explicitly
+		var configs []mutate.FuzzingConfig
+		if *config != "" {
+			configs, err = readConfig(*config, &options)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				return
 			}
-
-		case "path-ext":
-			if mode == "multiple" {
-				switch ftype {
-				case "replace":
-					modifiedURL = rePathExt.ReplaceAllString(url, "/${1}."+payload)
-				case "prefix":
-					modifiedURL = rePathExt.ReplaceAllString(url, "/${1}."+payload+"${2}")
-				case "postfix":
-					modifiedURL = rePathExt.ReplaceAllString(url, "${0}"+payload)
-				default:
-					fmt.Fprintf(os.Stderr, "Invalid fuzzing type: %s\n", ftype)
-					return
-				}
-				fmt.Println(modifiedURL)
-			} else if mode == "single" {
-				if *verbose {
-					fmt.Println("You cannot use -fuzzing-mode single with -fuzzing-part path-segment")
-				}
+		} else {
+			// The part settings of the default config.yaml still apply
+			if _, err := loadConfigs("", &options); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				return
 			}
-
-		case "headers":
-			if mode == "multiple" {
-				switch ftype {
-				case "replace":
-					modifiedURL = reUserAgent.ReplaceAllString(url, "${1}"+payload)
-				case "prefix":
-					modifiedURL = reUserAgent.ReplaceAllString(url, "${1}"+payload+"${2}")
-				case "postfix":
-					modifiedURL = reUserAgent.ReplaceAllString(url, "${1}${2}"+payload)
-				default:
-					fmt.Fprintf(os.Stderr, "Invalid fuzzing type: %s\n", ftype)
-					return
-				}
-				fmt.Println(modifiedURL)
-			} else if mode == "single" {
-				// Handle single replacement mode if needed
-				if *verbose {
-					fmt.Println("You cannot use -fuzzing-mode single with -fuzzing-part headers")
-				}
+			if isFlagSet("fuzzing-part") || *attack != "" {
+				configs = flagConfig()
 			}
-
-		default:
-			fmt.Fprintf(os.Stderr, "Invalid fuzzing part: %s\n", part)
-			return
 		}
-	}
 
-	// Function to check if a line is an injectable header
-	isInjectableHeader := func(line string) bool {
-		return reHeader.MatchString(line)
+		processRaw(newMutator(configs), *raw, *ignoreLines, *output)
+		printCountTotal()
+		return
 	}
 
-	// Function to add payload to injectable headers
-	fuzzHeader := func(line, payload string) string {
-		if reHeader.MatchString(line) {
-			return reHeader.ReplaceAllString(line, "${1}: ${2}"+payload)
+	// Load config if provided or use default
+	configs, err := loadConfigs(*config, &options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return
+	}
+	if len(configs) == 0 {
+		configs = flagConfig()
+	}
+	// URLs have no headers, so header parts are printed as requests
+	if *format == mutate.FormatURL && usesHeaderParts(configs) {
+		if isFlagSet("format") {
+			fmt.Fprintf(os.Stderr, "Error: header parts cannot be used with -format url, use raw, curl or jsonl\n")
+			os.Exit(1)
 		}
-		return line
+		*format = mutate.FormatRaw
 	}
+	m := newMutator(configs)
+	defer printCountTotal()
 
 	// Handle URL passed via the `-u` flag
 	if *url != "" {
-		payloads, err := getPayloads(*payload)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "%v\n", err)
-			return
-		}
-
-		// Load config if provided or use default
-		var configs []FuzzingConfig
-		configPath := *config
-		if configPath == "" {
-			// Use default config path
-			defaultPath, err := ensureDefaultConfig()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Could not use default config.yaml: %v\n", err)
-			} else {
-				configPath = defaultPath
-			}
-		}
-
-		if configPath != "" {
-			loadedConfigs, err := loadConfig(configPath)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "%v\n", err)
-				return
-			}
-			configs = loadedConfigs
-		}
-
-		for _, p := range payloads {
-			if len(configs) > 0 {
-				// Process with each config from file
-				for _, cfg := range configs {
-					if cfg.FuzzingPart == "all" {
-						for _, part := range allFuzzingParts {
-							processURL(*url, strings.TrimSpace(p), cfg.FuzzingMode, cfg.FuzzingType, part)
-						}
-					} else {
-						processURL(*url, strings.TrimSpace(p), cfg.FuzzingMode, cfg.FuzzingType, cfg.FuzzingPart)
-					}
-				}
-			} else {
-				// Use flag-based configuration
-				if *fuzzingPart == "all" {
-					for _, part := range allFuzzingParts {
-						processURL(*url, strings.TrimSpace(p), *fuzzingMode, *fuzzingType, part)
-					}
-				} else {
-					processURL(*url, strings.TrimSpace(p), *fuzzingMode, *fuzzingType, *fuzzingPart)
-				}
-			}
-		}
+		printURL(m, *url)
 		return
 	}
 
-	// Handle URLs passed via the `-list` flag (file input)
+	// Handle URLs passed via the `-list` flag (file input) or standard input (pipe)
+	input := os.Stdin
 	if *list != "" {
 		file, err := os.Open(*list)
 		if err != nil {
@@ -592,298 +494,138 @@ func main() {
 			return
 		}
 		defer file.Close()
+		input = file
+	}
 
-		payloads, err := getPayloads(*payload)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "%v\n", err)
-			return
-		}
-
-		// Load config if provided or use default
-		var configs []FuzzingConfig
-		configPath := *config
-		if configPath == "" {
-			// Use default config path
-			defaultPath, err := ensureDefaultConfig()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Could not use default config.yaml: %v\n", err)
-			} else {
-				configPath = defaultPath
-			}
-		}
-
-		if configPath != "" {
-			loadedConfigs, err := loadConfig(configPath)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "%v\n", err)
-				return
-			}
-			configs = loadedConfigs
-		}
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		printURL(m, scanner.Text())
+	}
 
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			url := scanner.Text()
-			for _, p := range payloads {
-				if len(configs) > 0 {
-					// Process with each config from file
-					for _, cfg := range configs {
-						if cfg.FuzzingPart == "all" {
-							for _, part := range allFuzzingParts {
-								processURL(url, strings.TrimSpace(p), cfg.FuzzingMode, cfg.FuzzingType, part)
-							}
-						} else {
-							processURL(url, strings.TrimSpace(p), cfg.FuzzingMode, cfg.FuzzingType, cfg.FuzzingPart)
-						}
-					}
-				} else {
-					// Use flag-based configuration
-					if *fuzzingPart == "all" {
-						for _, part := range allFuzzingParts {
-							processURL(url, strings.TrimSpace(p), *fuzzingMode, *fuzzingType, part)
-						}
-					} else {
-						processURL(url, strings.TrimSpace(p), *fuzzingMode, *fuzzingType, *fuzzingPart)
-					}
-				}
-			}
-		}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+	}
+}
 
-		if err := scanner.Err(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-		}
+// Function to process Burp Suite raw request files and save the modified requests
+func processRaw(m *mutate.Mutator, raw, ignoreLines, output string) {
+	// Check if the path is a directory or a file
+	fileInfo, err := os.Stat(raw)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error accessing raw request path: %v\n", err)
 		return
 	}
 
-	// Handle Burp Suite raw request data passed via the `-raw` flag
-	if *raw != "" {
-		// Check if the path is a directory or a file
-		fileInfo, err := os.Stat(*raw)
+	var filesToProcess []string
+
+	if fileInfo.IsDir() {
+		// Read all files from the directory
+		entries, err := os.ReadDir(raw)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error accessing raw request path: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error reading directory: %v\n", err)
 			return
 		}
 
-		var filesToProcess []string
-
-		if fileInfo.IsDir() {
-			// Read all files from the directory
-			entries, err := os.ReadDir(*raw)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading directory: %v\n", err)
-				return
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				filesToProcess = append(filesToProcess, filepath.Join(raw, entry.Name()))
 			}
+		}
 
-			for _, entry := range entries {
-				if !entry.IsDir() {
-					filesToProcess = append(filesToProcess, filepath.Join(*raw, entry.Name()))
-				}
-			}
+		if len(filesToProcess) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: No files found in directory: %s\n", raw)
+			return
+		}
+	} else {
+		// Single file
+		filesToProcess = append(filesToProcess, raw)
+	}
 
-			if len(filesToProcess) == 0 {
-				fmt.Fprintf(os.Stderr, "Error: No files found in directory: %s\n", *raw)
-				return
-			}
+	// Determine which ignore-lines file to use
+	ignoreLinesPath := ignoreLines
+	if ignoreLinesPath == "" {
+		// Use default path if --ignore-lines is not provided
+		defaultPath, err := ensureDefaultFile("ignore-lines.txt", getDefaultIgnoreLinesPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not use default ignore-lines.txt: %v\n", err)
 		} else {
-			// Single file
-			filesToProcess = append(filesToProcess, *raw)
+			ignoreLinesPath = defaultPath
 		}
+	}
 
-		payloads, err := getPayloads(*payload)
+	// Load ignore patterns
+	if ignoreLinesPath != "" {
+		lines, err := mutate.LoadIgnoreLines(ignoreLinesPath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 			return
 		}
+		m.IgnoreLines = lines
+	}
 
-		// Prepare the ignore lines set
-		ignoreSet := make(map[string]bool)
-
-		// Determine which ignore-lines file to use
-		ignoreLinesPath := *ignoreLines
-		if ignoreLinesPath == "" {
-			// Use default path if --ignore-lines is not provided
-			defaultPath, err := ensureDefaultIgnoreLines()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Could not use default ignore-lines.txt: %v\n", err)
-			} else {
-				ignoreLinesPath = defaultPath
-			}
+	// Determine output directory
+	outputDir := output
+	if outputDir == "" {
+		// Use default output directory
+		defaultOutput, err := getDefaultOutputPath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not get default output path: %v\n", err)
+		} else {
+			outputDir = defaultOutput
 		}
+	}
 
-		// Load ignore patterns
-		if ignoreLinesPath != "" {
-			lines, err := getIgnoreLines(ignoreLinesPath)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "%v\n", err)
-				return
-			}
-			for _, line := range lines {
-				ignoreSet[strings.TrimSpace(line)] = true
-			}
+	// Create output directory if needed
+	if outputDir != "" {
+		if err := ensureOutputDir(outputDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
 		}
+	}
 
-		// Determine output directory
-		var outputDir string
-		var useOutputDir bool
-		if *output != "" {
-			outputDir = *output
-			useOutputDir = true
-		} else {
-			// Use default output directory
-			defaultOutput, err := getDefaultOutputPath()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Could not get default output path: %v\n", err)
-			} else {
-				outputDir = defaultOutput
-				useOutputDir = true
-			}
+	// Process each file
+	for _, filePath := range filesToProcess {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file %s: %v\n", filePath, err)
+			continue
 		}
 
-		// Create output directory if needed
-		if useOutputDir {
-			if err := ensureOutputDir(outputDir); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				return
-			}
+		if *countOnly {
+			n, err := m.CountRaw(string(content))
+			fmt.Printf("%d %s\n", n, filePath)
+			countTotal = min(countTotal, math.MaxInt-n) + n
+			reportErrors(err)
+			continue
 		}
 
-		// Process each file
-		for _, filePath := range filesToProcess {
-			content, err := os.ReadFile(filePath)
+		// Prepare output file if output directory is specified
+		var outputFile *os.File
+		if outputDir != "" {
+			outputFilePath := filepath.Join(outputDir, filepath.Base(filePath))
+			outputFile, err = os.Create(outputFilePath)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading file %s: %v\n", filePath, err)
+				fmt.Fprintf(os.Stderr, "Error creating output file %s: %v\n", outputFilePath, err)
 				continue
 			}
+			defer outputFile.Close()
 
-			// Prepare output file if output directory is specified
-			var outputFile *os.File
-			if useOutputDir {
-				baseFileName := filepath.Base(filePath)
-				outputFilePath := filepath.Join(outputDir, baseFileName)
-				outputFile, err = os.Create(outputFilePath)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error creating output file %s: %v\n", outputFilePath, err)
-					continue
-				}
-				defer outputFile.Close()
-
-				if *verbose {
-					fmt.Fprintf(os.Stderr, "[+] Saving modified request to: %s\n", outputFilePath)
-				}
-			}
-
-			// Process the content line by line
-			for _, payload := range payloads {
-				scanner := bufio.NewScanner(strings.NewReader(string(content)))
-				for scanner.Scan() {
-					line := scanner.Text()
-
-					// Check if the line should be ignored
-					ignore := false
-					for prefix := range ignoreSet {
-						if strings.HasPrefix(line, prefix) {
-							ignore = true
-							break
-						}
-					}
-
-					var modifiedLine string
-					// If the line is ignored, print it as-is without fuzzing
-					if ignore {
-						modifiedLine = line
-					} else if isInjectableHeader(line) {
-						// If it's an injectable header, append payload to the end
-						modifiedLine = fuzzHeader(line, strings.TrimSpace(payload))
-					} else {
-						// Check if line contains parameters or needs fuzzing
-						if reValue.MatchString(line) {
-							modifiedLine = reValue.ReplaceAllString(line, "="+strings.TrimSpace(payload))
-						} else {
-							modifiedLine = line
-						}
-					}
-
-					// Print to stdout
-					fmt.Println(modifiedLine)
-
-					// Write to output file if specified
-					if outputFile != nil {
-						fmt.Fprintln(outputFile, modifiedLine)
-					}
-				}
-
-				if err := scanner.Err(); err != nil {
-					fmt.Fprintf(os.Stderr, "Error reading raw data: %v\n", err)
-				}
-
-				// Separate different payload outputs with a newline
-				fmt.Println()
-				if outputFile != nil {
-					fmt.Fprintln(outputFile)
-				}
+			if *verbose {
+				fmt.Fprintf(os.Stderr, "[+] Saving modified request to: %s\n", outputFilePath)
 			}
 		}
-		return
-	}
-
-	// Handle URLs passed via standard input (pipe)
-	payloads, err := getPayloads(*payload)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%v\n", err)
-		return
-	}
 
-	// Load config if provided or use default
-	var configs []FuzzingConfig
-	configPath := *config
-	if configPath == "" {
-		// Use default config path
-		defaultPath, err := ensureDefaultConfig()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Could not use default config.yaml: %v\n", err)
-		} else {
-			configPath = defaultPath
-		}
-	}
-
-	if configPath != "" {
-		loadedConfigs, err := loadConfig(configPath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "%v\n", err)
-			return
-		}
-		configs = loadedConfigs
-	}
-
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		url := scanner.Text()
-		for _, p := range payloads {
-			if len(configs) > 0 {
-				// Process with each config from file
-				for _, cfg := range configs {
-					if cfg.FuzzingPart == "all" {
-						for _, part := range allFuzzingParts {
-							processURL(url, strings.TrimSpace(p), cfg.FuzzingMode, cfg.FuzzingType, part)
-						}
-					} else {
-						processURL(url, strings.TrimSpace(p), cfg.FuzzingMode, cfg.FuzzingType, cfg.FuzzingPart)
-					}
-				}
-			} else {
-				// Use flag-based configuration
-				if *fuzzingPart == "all" {
-					for _, part := range allFuzzingParts {
-						processURL(url, strings.TrimSpace(p), *fuzzingMode, *fuzzingType, part)
-					}
-				} else {
-					processURL(url, strings.TrimSpace(p), *fuzzingMode, *fuzzingType, *fuzzingPart)
-				}
+		err = m.EachRaw(string(content), func(v mutate.Variant) {
+			// Separate different payload outputs with a blank line
+			value := v.Value
+			if !strings.HasSuffix(value, "\n") {
+				value += "\n"
 			}
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+			fmt.Println(value)
+			if outputFile != nil {
+				fmt.Fprintln(outputFile, value)
+			}
+		})
+		reportErrors(err)
 	}
 }